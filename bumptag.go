@@ -5,308 +5,578 @@
 package main
 
 import (
-	"bytes"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"os/signal"
-	"strconv"
+	"path/filepath"
 	"strings"
+	"sync"
+	"text/template"
 
-	"github.com/coreos/go-semver/semver"
+	"github.com/sv-tools/bumptag/bumptag"
 )
 
-var (
-	version   = "0.0.0"
-	tagPrefix = "v"
-)
+var version = "0.0.0"
 
 const (
-	defaultRemote = "origin"
-	defaultEditor = "vim"
+	defaultEditor    = "vim"
+	commandSuggest   = "suggest"
+	commandChangelog = "changelog"
 )
 
-func realGit(input string, arg ...string) (string, error) {
-	cmd := exec.Command("git", arg...)
-	if len(input) > 0 {
-		cmd.Stdin = strings.NewReader(input)
-	}
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		text := fmt.Sprintf(
-			"command '%s' failed: %s",
-			strings.Join(cmd.Args, " "),
-			err.Error(),
-		)
-		errText := strings.TrimSpace(stderr.String())
-		if len(errText) > 0 {
-			text += "\n" + errText
-		}
-		return "", errors.New(text)
+// changeLogInput returns the stdin if the change log is passed by a pipe.
+func changeLogInput() io.Reader {
+	stat, _ := os.Stdin.Stat()
+	if (stat.Mode() & os.ModeCharDevice) == 0 {
+		return os.Stdin
 	}
-	return strings.TrimSpace(stdout.String()), nil
+	return nil
 }
 
-var git = realGit
-
-func noOutputGit(input string, arg ...string) error {
-	_, err := git(input, arg...)
-	return err
+func createFlag(flagSet *flag.FlagSet, name, short string, value bool, usage string) *bool {
+	p := flagSet.Bool(name, value, usage)
+	if len(short) > 0 {
+		flagSet.BoolVar(p, short, value, usage)
+	}
+	return p
 }
 
-func disableGPG() (string, error) {
-	output, _ := git("", "config", "--local", "--get", "log.showSignature")
-	if err := noOutputGit("", "config", "--local", "log.showSignature", "false"); err != nil {
-		return "", err
+func createStringFlag(flagSet *flag.FlagSet, name, short, value, usage string) *string {
+	p := flagSet.String(name, value, usage)
+	if len(short) > 0 {
+		flagSet.StringVar(p, short, value, usage)
 	}
-	return output, nil
+	return p
 }
 
-func restoreGPG(oldValue string) error {
-	if len(oldValue) > 0 {
-		return noOutputGit("", "config", "--local", "log.showSignature", oldValue)
-	}
-	return noOutputGit("", "config", "--local", "--unset", "log.showSignature")
+// stringsFlag is a flag which can be passed several times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
 }
 
-func setUpGPG() (func(), error) {
-	oldValue, err := disableGPG()
-	if err != nil {
-		return nil, err
-	}
-	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, os.Interrupt)
-	go func() {
-		<-signalChan
-		_ = restoreGPG(oldValue)
-		os.Exit(42)
-	}()
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
 
-	return func() {
-		signal.Stop(signalChan)
-		_ = restoreGPG(oldValue)
-	}, nil
+type bumptagArgs struct {
+	flagSet       *flag.FlagSet
+	command       string
+	edit          *bool
+	dryRun        *bool
+	silent        *bool
+	autoPush      *bool
+	major         *bool
+	minor         *bool
+	patch         *bool
+	version       *bool
+	findTag       *bool
+	auto          *bool
+	pre           *string
+	promote       *bool
+	metadata      *string
+	strategy      *string
+	module        *string
+	allModules    *bool
+	fixModulePath *bool
+	backend       *string
+	prefix        *string
+	remote        *string
+	sign          *bool
+	signKey       *string
+	noSign        *bool
+	templateFile  *string
+	group         *bool
+	changelogFile *string
+	all           *bool
+	annotations   *bool
+	output        *string
+	ci            *bool
+	debug         *bool
+	release       *string
+	releaseURL    *string
+	releaseAssets stringsFlag
+	skipChecks    stringsFlag
+	bumpFiles     stringsFlag
+	bumpMessage   *string
+	check         *bool
+	replacements  []*bumptag.Replacement
+	hooks         bumptag.Hooks
+	defaultLevel  bumptag.BumpLevel
+	editor        string
+	template      *template.Template
+	sections      []*bumptag.Section
+	project       string
+	branches      []string
 }
 
-func gitConfig(name, defaultValue string) string {
-	output, err := git("", "config", "--get", name)
-	if err != nil {
-		return defaultValue
-	}
-	return output
+func (f *bumptagArgs) usage() {
+	output := `Usage: bumptag [<tagname>]
+       bumptag suggest [--module <dir>] [--strategy <strategy>]
+       bumptag changelog [--all [--annotations] [--changelog-file <file>] [--dry-run]] [--module <dir>] [--group]
+
+    <tagname>       The name of the tag to create, must be Semantic Versions 2.0.0 (http://semver.org)
+    -e, --edit      Edit an annotation
+    -r, --dry-run   Prints an annotation for the new tag
+    -s, --silent    Do not show the created tag and the warnings
+    -a, --auto-push Push the created tag automatically
+    -m, --major     Increment the MAJOR version
+    -n, --minor     Increment the MINOR version (default)
+    -p, --patch     Increment the PATCH version
+        --version   Show a version of the bumptag tool
+        --find-tag  Show the last tag, can be useful for CI tools
+        --auto      Detect the bump level from the Conventional Commits messages
+        --pre       Create a pre-release of the given channel, e.g. '--pre rc' creates v1.3.0-rc.1, then v1.3.0-rc.2
+        --promote   Turn the latest pre-release into the final release, e.g. v1.3.0-rc.2 -> v1.3.0
+        --metadata  Add the build metadata, supports the placeholders {sha}, {date}, {build} and {env:NAME},
+                    e.g. '--metadata build.{build}.{sha}' creates v1.2.0+build.451.abc1234
+        --strategy  The strategy to find the latest tag:
+                      describe          - the nearest tag reachable from HEAD (default)
+                      highest           - the highest version of all tags
+                      highest-reachable - the highest version of the tags reachable from HEAD
+        --module    Tag the Go module in the given directory, e.g. '--module tools/foo' creates tools/foo/v1.2.0,
+                    the change log contains only the commits touching the directory
+        --all-modules
+                    Tag all Go modules of the repository changed since their latest tags,
+                    the bump level is the same for all modules or detected per module with --auto
+        --fix-module-path
+                    Update the module path in go.mod and the imports in a new commit when the MAJOR version
+                    does not match the module path, e.g. 'example.com/foo' -> 'example.com/foo/v2',
+                    otherwise such tags are refused
+        --backend   The way to access the git repository:
+                      auto - the git binary if it is installed, otherwise go (default)
+                      cli  - run the git binary
+                      go   - read and write the repository directly, the signed tags are not supported
+        --prefix    The prefix of the versions in the tag names (default 'v'),
+                    e.g. '--prefix release-' creates release-1.2.0
+        --remote    The remote to push the tag to (default: the remote of the current branch)
+        --sign      Sign the tag in the format of gpg.format: openpgp, ssh or x509
+                    (default: the value of tag.gpgSign or commit.gpgsign) and verify the signature by 'git tag -v',
+                    the ssh signatures require gpg.ssh.allowedSignersFile
+        --sign-key  Sign the tag with the key (default: the value of user.signingkey), implies --sign,
+                    e.g. '--sign-key ~/.ssh/id_ed25519.pub'
+        --no-sign   Do not sign the tag
+        --template-file
+                    Render the annotation by the text/template in the file,
+                    e.g. '{{.TagName}}' or '{{range .Commits}}{{.Subject}} by {{.Author}}{{end}}'
+        --group     Group the change log into the sections by the Conventional Commits types:
+                    Breaking Changes, Features, Bug Fixes, Performance and Other
+        --output    The output format: text (default) or json, e.g. '--output json' prints the previous and new tags,
+                    the version components, the bump level, the commits, the signed flag, the pushed remote
+                    and the annotation; --find-tag and --dry-run print JSON too
+        --ci        Write the tag, the previous tag, the version and the change log for the next steps of the CI:
+                    GitHub Actions - the step outputs to $GITHUB_OUTPUT and the step summary,
+                    GitLab CI      - the dotenv report bumptag.env with BUMPTAG_TAG, BUMPTAG_PREVIOUS_TAG
+                                     and BUMPTAG_VERSION variables
+        --changelog-file
+                    Add the change log to the file in the Keep a Changelog format (https://keepachangelog.com),
+                    e.g. '--changelog-file CHANGELOG.md', and commit it before creating the tag
+        --release   Create the release of the pushed tag on the forge: github, gitea or gitlab,
+                    the notes are the annotation without the first line and the pre-releases are marked as such
+                    (except on GitLab), the token is read from $BUMPTAG_RELEASE_TOKEN or $GITHUB_TOKEN,
+                    $GITEA_TOKEN, $GITLAB_TOKEN, requires --auto-push
+        --release-url
+                    The base URL of the API of the forge (default: detected from the URL of the remote),
+                    e.g. '--release-url https://git.example.com/api/v1'
+        --release-asset
+                    Upload the files matching the glob pattern to the release, can be passed several times,
+                    e.g. '--release-asset dist/*.tar.gz'
+        --bump-files
+                    Update the version in the files and commit them before creating the tag, can be passed several
+                    times or as a comma separated list, e.g. '--bump-files VERSION,package.json,main.go':
+                      VERSION        - the whole content
+                      package.json   - the top level "version" field
+                      Cargo.toml     - the version of the [package] or [workspace.package] section
+                      pyproject.toml - the version of the [project] or [tool.poetry] section
+                      Chart.yaml     - the version and appVersion fields
+                      *.go           - the package level 'Version' or 'version' constant or variable,
+                                       e.g. 'const Version = "1.2.0"'
+        --bump-message
+                    The text/template of the message of the commit of the version files
+                    (default 'Bump version to {{.TagName}}')
+        --check     Check the files of --bump-files and of the 'replace' setting match the latest tag,
+                    fails with the list of the outdated files otherwise, useful for CI tools
+        --debug     Show the git commands and the full error output of the failed ones
+        --skip-check
+                    Skip the pre-flight check before creating the tag, can be passed several times
+                    or as a comma separated list, e.g. '--skip-check clean,behind':
+                      clean   - the tracked files have no uncommitted changes
+                      branch  - HEAD is on a release branch, see the 'branches' setting
+                      behind  - the branch is not behind its upstream after fetching it
+                      tag     - the tag does not exist locally or in the remote, the remote exists with --auto-push
+                      version - the new version is greater than the previous one
+
+    The defaults of the flags can be set in the .bumptag.yaml or .bumptag.toml file in the root of the repository,
+    by the bumptag.* git config options or by the BUMPTAG_* environment variables, the later sources win.
+
+    The pre-bump, pre-tag, post-tag and post-push settings are the shell commands run before updating the version
+    files, before and after creating the tag and after pushing it with the BUMPTAG_NEW_TAG, BUMPTAG_PREV_TAG,
+    BUMPTAG_VERSION and BUMPTAG_CHANGELOG_PATH environment variables, a failing hook aborts the release.
+
+    The change log is automatically generated from git commits from the previous tag or can be passed by <stdin>.
+
+    suggest         Compare the exported API of the Go module at the latest tag and at HEAD and recommend
+                    the bump level: MAJOR for removed or changed exports, MINOR for added exports, otherwise PATCH
+
+    changelog       Show the change log of the next release
+        --all       Regenerate the change log file (CHANGELOG.md by default) from all final releases reachable
+                    from HEAD using the commits between the consecutive tags, the file is not committed,
+                    e.g. 'bumptag changelog --all --dry-run' shows the file instead of writing it
+        --annotations
+                    Use the annotations of the tags without the first line instead of the commits when available
+
+    Exit codes:
+        0           Success
+        1           Other errors
+        2           Invalid flags
+        3           The working tree has uncommitted changes
+        4           The tag already exists
+        5           Invalid version, e.g. not a Semantic Version or not matching the Go module path
+        6           The remote is not found
+        7           The git command failed
+        8           The pre-flight check failed
+        42          Interrupted by Ctrl-C`
+	fmt.Println(output)
 }
 
-func gitConfigBool(name string, defaultValue bool) bool {
-	output := gitConfig(name, strconv.FormatBool(defaultValue))
-	value, err := strconv.ParseBool(output)
-	if err != nil {
-		return defaultValue
+func (f *bumptagArgs) parse() error {
+	f.flagSet.Usage = f.usage
+	arguments := os.Args[1:]
+	if len(arguments) > 0 && (arguments[0] == commandSuggest || arguments[0] == commandChangelog) {
+		f.command, arguments = arguments[0], arguments[1:]
+	}
+	if err := f.flagSet.Parse(arguments); err != nil {
+		return err
 	}
-	return value
+	if err := f.validate(); err != nil {
+		return flagError(err.Error())
+	}
+	return nil
 }
 
-func findTag() (*semver.Version, string, error) {
-	output, err := git("", "tag")
-	if err != nil {
-		return nil, "", err
+// validate checks the values of the flags and the flags used together, the flag package checks only their types.
+func (f *bumptagArgs) validate() error {
+	if *f.noSign && f.isSet("sign", "sign-key") {
+		return errors.New("--no-sign cannot be used with --sign or --sign-key")
 	}
-	if output == "" {
-		return &semver.Version{}, "", nil
+	if *f.allModules {
+		if err := f.validateAllModules(); err != nil {
+			return err
+		}
 	}
-	output, err = git("", "describe", "--tags", "--abbrev=0")
-	if err != nil {
-		return nil, "", err
+	if err := validateOutput(*f.output); err != nil {
+		return err
 	}
-	currentTagName := output
-	if !strings.HasPrefix(output, tagPrefix) {
-		tagPrefix = ""
+	if err := bumptag.ValidateStrategy(*f.strategy); err != nil {
+		return err
 	}
-	dotParts := strings.SplitN(output, ".", 3)
-	for i := 3 - len(dotParts); i > 0; i-- {
-		output += ".0"
+	if err := bumptag.ValidateBackend(*f.backend); err != nil {
+		return err
 	}
-
-	tag, err := semver.NewVersion(strings.TrimPrefix(output, tagPrefix))
-	if err != nil {
-		return nil, "", err
+	if len(*f.release) > 0 {
+		if err := bumptag.ValidateForge(*f.release); err != nil {
+			return err
+		}
+	}
+	if len(*f.pre) > 0 {
+		if err := bumptag.ValidatePreReleaseChannel(*f.pre); err != nil {
+			return err
+		}
 	}
-	return tag, currentTagName, nil
+	for _, name := range f.skipCheckNames() {
+		if err := bumptag.ValidateCheck(name); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func createTag(tagName, annotation string, sign bool) error {
-	args := []string{"tag", "-F-"}
-	if sign {
-		args = append(args, "--sign")
-	}
-	args = append(args, tagName)
-	return noOutputGit(annotation, args...)
+// validateAllModules checks the flags which cannot be used with --all-modules.
+func (f *bumptagArgs) validateAllModules() error {
+	switch {
+	case f.flagSet.NArg() > 0 || len(*f.module) > 0:
+		return errors.New("the tag name and --module cannot be used with --all-modules")
+	case len(f.command) > 0:
+		return fmt.Errorf("the %s command cannot be used with --all-modules", f.command)
+	case *f.ci:
+		return errors.New("--ci cannot be used with --all-modules")
+	case *f.check:
+		return errors.New("--check cannot be used with --all-modules")
+	}
+	return nil
 }
 
-func showTag(tagName string) (string, error) {
-	return git("", "show", tagName)
+func newBumptagArgs() *bumptagArgs {
+	flagSet := flag.NewFlagSet("Bumptag", flag.ExitOnError)
+	args := &bumptagArgs{
+		flagSet:       flagSet,
+		edit:          createFlag(flagSet, "edit", "e", false, "Edit an annotation"),
+		dryRun:        createFlag(flagSet, "dry-run", "r", false, "Prints an annotation for the new tag"),
+		silent:        createFlag(flagSet, "silent", "s", false, "Do not show the created tag"),
+		autoPush:      createFlag(flagSet, "auto-push", "a", false, "Push the created tag automatically"),
+		major:         createFlag(flagSet, "major", "m", false, "Increment the MAJOR version"),
+		minor:         createFlag(flagSet, "minor", "n", false, "Increment the MINOR version (default)"),
+		patch:         createFlag(flagSet, "patch", "p", false, "Increment the PATCH version"),
+		version:       createFlag(flagSet, "version", "", false, "Show a version of the bumptag tool"),
+		findTag:       createFlag(flagSet, "find-tag", "", false, "Show the latest tag, can be useful for CI tools"),
+		auto:          createFlag(flagSet, "auto", "", false, "Detect the bump level from the Conventional Commits messages"),
+		pre:           createStringFlag(flagSet, "pre", "", "", "Create a pre-release of the given channel"),
+		promote:       createFlag(flagSet, "promote", "", false, "Turn the latest pre-release into the final release"),
+		metadata:      createStringFlag(flagSet, "metadata", "", "", "Add the build metadata"),
+		strategy:      createStringFlag(flagSet, "strategy", "", bumptag.StrategyDescribe, "The strategy to find the latest tag"),
+		module:        createStringFlag(flagSet, "module", "", "", "Tag the Go module in the given directory"),
+		allModules:    createFlag(flagSet, "all-modules", "", false, "Tag all changed Go modules of the repository"),
+		fixModulePath: createFlag(flagSet, "fix-module-path", "", false, "Update the module path for a new MAJOR version"),
+		backend:       createStringFlag(flagSet, "backend", "", bumptag.BackendAuto, "The way to access the git repository"),
+		prefix:        createStringFlag(flagSet, "prefix", "", bumptag.TagPrefix, "The prefix of the versions in tag names"),
+		remote:        createStringFlag(flagSet, "remote", "", "", "The remote to push the tag to"),
+		sign:          createFlag(flagSet, "sign", "", false, "Sign the tag"),
+		signKey:       createStringFlag(flagSet, "sign-key", "", "", "Sign the tag with the key"),
+		noSign:        createFlag(flagSet, "no-sign", "", false, "Do not sign the tag"),
+		templateFile:  createStringFlag(flagSet, "template-file", "", "", "Render the annotation by the template in the file"),
+		group:         createFlag(flagSet, "group", "", false, "Group the change log into the sections"),
+		changelogFile: createStringFlag(flagSet, "changelog-file", "", "", "Add the change log to the file and commit it"),
+		output:        createStringFlag(flagSet, "output", "", outputText, "The output format: text or json"),
+		ci:            createFlag(flagSet, "ci", "", false, "Write the results for the next steps of the CI pipeline"),
+		all:           createFlag(flagSet, "all", "", false, "Regenerate the change log file from all releases"),
+		annotations:   createFlag(flagSet, "annotations", "", false, "Use the annotations of the tags"),
+		debug:         createFlag(flagSet, "debug", "", false, "Show the git commands and their errors"),
+		release:       createStringFlag(flagSet, "release", "", "", "Create the release of the pushed tag on the forge"),
+		releaseURL:    createStringFlag(flagSet, "release-url", "", "", "The base URL of the API of the forge"),
+		bumpMessage:   createStringFlag(flagSet, "bump-message", "", "", "The message of the commit of the version files"),
+		check:         createFlag(flagSet, "check", "", false, "Check the version files match the latest tag"),
+		defaultLevel:  bumptag.BumpMinor,
+	}
+	flagSet.Var(&args.releaseAssets, "release-asset", "Upload the files matching the glob pattern to the release")
+	flagSet.Var(&args.skipChecks, "skip-check", "Skip the pre-flight check")
+	flagSet.Var(&args.bumpFiles, "bump-files", "Update the version in the files and commit them")
+	return args
 }
 
-func getChangeLog(tagName string) (string, error) {
-	stat, _ := os.Stdin.Stat()
-	if (stat.Mode() & os.ModeCharDevice) == 0 {
-		output, err := ioutil.ReadAll(os.Stdin)
-		if err != nil {
-			return "", err
+// isSet checks if any of the flags is passed in the command line.
+func (f *bumptagArgs) isSet(names ...string) bool {
+	var found bool
+	f.flagSet.Visit(func(fl *flag.Flag) {
+		for _, name := range names {
+			if fl.Name == name {
+				found = true
+			}
 		}
-		return string(output), nil
-	}
+	})
+	return found
+}
 
-	args := []string{"log", "--pretty=%h %s", "--no-merges"}
-	if len(tagName) > 0 {
-		args = append(args, tagName+"..HEAD")
+// applyConfig uses the configuration of the repository for the flags not passed in the command line.
+func (f *bumptagArgs) applyConfig(c *bumptag.Config) {
+	if !f.isSet("prefix") {
+		*f.prefix = c.Prefix
 	}
-	output, err := git("", args...)
-	if err != nil {
-		return "", err
+	if !f.isSet("strategy") {
+		*f.strategy = c.Strategy
 	}
-	var res []string
-	for _, line := range strings.Split(output, "\n") {
-		res = append(res, "* "+line)
+	if !f.isSet("remote") {
+		*f.remote = c.Remote
+	}
+	f.applySignConfig(c)
+	if !f.isSet("auto-push", "a") {
+		*f.autoPush = c.AutoPush
+	}
+	if !f.isSet("auto") {
+		*f.auto = c.Auto
 	}
-	return strings.Join(res, "\n"), nil
+	if !f.isSet("group") {
+		*f.group = c.Group
+	}
+	if !f.isSet("changelog-file") {
+		*f.changelogFile = c.ChangelogFile
+	} else if path, err := filepath.Abs(*f.changelogFile); err == nil {
+		// the flag is relative to the current directory, but the setting is relative to the root of the repository
+		*f.changelogFile = path
+	}
+	if !f.isSet("release") {
+		*f.release = c.Release
+	}
+	if !f.isSet("release-url") {
+		*f.releaseURL = c.ReleaseURL
+	}
+	if !f.isSet("release-asset") {
+		f.releaseAssets = c.ReleaseAssets
+	}
+	if !f.isSet("bump-files") {
+		f.bumpFiles = c.BumpFiles
+	} else {
+		f.bumpFiles = absPaths(f.bumpFiles)
+	}
+	if !f.isSet("bump-message") {
+		*f.bumpMessage = c.BumpMessage
+	}
+	f.replacements = c.Replacements
+	f.hooks = c.Hooks
+	f.project = c.ReleaseProject
+	f.branches = c.Branches
+	// the checks skipped by the flag are added to the configured ones
+	f.skipChecks = append(c.SkipChecks, f.skipChecks...)
+	f.defaultLevel = c.Level
+	f.editor = c.Editor
+	f.sections = c.Sections
 }
 
-func parseRemote(remote string) (string, error) {
-	for _, part := range strings.Split(remote, " ") {
-		if strings.HasPrefix(part, "[") {
-			part = strings.Trim(part, "[]")
-			names := strings.SplitN(part, "/", 2)
-			if len(names) != 2 {
-				return "", fmt.Errorf("cannot determine a remote name: %s", part)
-			}
-			return names[0], nil
-		}
+// applySignConfig uses the signing configuration for the flags not passed in the command line,
+// --sign-key implies --sign and --no-sign wins over the configuration.
+func (f *bumptagArgs) applySignConfig(c *bumptag.Config) {
+	if !f.isSet("sign-key") {
+		*f.signKey = c.SignKey
+	}
+	switch {
+	case *f.noSign:
+		*f.sign = false
+	case f.isSet("sign-key") && !f.isSet("sign"):
+		*f.sign = true
+	case !f.isSet("sign"):
+		*f.sign = c.Sign
 	}
-	return "", fmt.Errorf("remote for the active branch '%s' not found", remote)
 }
 
-func getRemote() (string, error) {
-	output, err := git("", "branch", "--list", "-vv")
-	if err != nil {
-		return "", err
+// warn returns the function printing the warnings of the repository to stderr,
+// nil if the output is silent or JSON.
+func (f *bumptagArgs) warn() func(message string) {
+	if *f.silent || *f.output == outputJSON {
+		return nil
 	}
-	for _, remote := range strings.Split(output, "\n") {
-		remote = strings.TrimSpace(remote)
-		if strings.HasPrefix(remote, "*") {
-			return parseRemote(remote)
-		}
+	return func(message string) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
 	}
-	return defaultRemote, nil
 }
 
-func pushTag(remote, tagName string) error {
-	return noOutputGit("", "push", remote, tagName)
+// absPaths splits the comma separated paths of the flag and makes them absolute,
+// the flags are relative to the current directory, but the settings are relative to the root of the repository.
+func absPaths(values []string) []string {
+	var res []string
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); len(name) == 0 {
+				continue
+			}
+			if path, err := filepath.Abs(name); err == nil {
+				name = path
+			}
+			res = append(res, name)
+		}
+	}
+	return res
 }
 
-func makeAnnotation(changeLog string, tagName string) string {
-	output := []string{
-		"Bump version " + tagName,
-		"",
-		changeLog,
+// loadTemplate parses the annotation template from the --template-file flag or the configuration.
+func (f *bumptagArgs) loadTemplate(c *bumptag.Config) (err error) {
+	text := c.Template
+	if len(*f.templateFile) > 0 {
+		data, err := os.ReadFile(*f.templateFile)
+		if err != nil {
+			return err
+		}
+		text = string(data)
 	}
-	return strings.Join(output, "\n")
+	if len(text) == 0 {
+		return nil
+	}
+	f.template, err = bumptag.ParseTemplate(text)
+	return err
 }
 
-func createFlag(flagSet *flag.FlagSet, name, short string, value bool, usage string) *bool {
-	p := flagSet.Bool(name, value, usage)
-	if len(short) > 0 {
-		flagSet.BoolVar(p, short, value, usage)
+func (f *bumptagArgs) bumpLevel() bumptag.BumpLevel {
+	switch true {
+	case *f.major:
+		return bumptag.BumpMajor
+	case *f.patch:
+		return bumptag.BumpPatch
+	case *f.minor:
+		return bumptag.BumpMinor
+	default:
+		return f.defaultLevel
 	}
-	return p
 }
 
-type bumptagArgs struct {
-	flagSet  *flag.FlagSet
-	edit     *bool
-	dryRun   *bool
-	silent   *bool
-	autoPush *bool
-	major    *bool
-	minor    *bool
-	patch    *bool
-	version  *bool
-	findTag  *bool
+func (f *bumptagArgs) explicitLevel() bool {
+	return *f.major || *f.minor || *f.patch
 }
 
-func (f *bumptagArgs) usage() {
-	output := `Usage: bumptag [<tagname>]
-
-    <tagname>       The name of the tag to create, must be Semantic Versions 2.0.0 (http://semver.org)
-    -e, --edit      Edit an annotation
-    -r, --dry-run   Prints an annotation for the new tag
-    -s, --silent    Do not show the created tag
-    -a, --auto-push Push the created tag automatically
-    -m, --major     Increment the MAJOR version
-    -n, --minor     Increment the MINOR version (default)
-    -p, --patch     Increment the PATCH version
-        --version   Show a version of the bumptag tool
-        --find-tag  Show the last tag, can be useful for CI tools
-
-    The change log is automatically generated from git commits from the previous tag or can be passed by <stdin>.`
-	fmt.Println(output)
+func (f *bumptagArgs) newBumper(repo *bumptag.Repo, input io.Reader) *bumptag.Bumper {
+	b := bumptag.NewBumper(repo)
+	b.Strategy = *f.strategy
+	b.Level = f.bumpLevel()
+	b.ExplicitLevel = f.explicitLevel()
+	b.Auto = *f.auto
+	b.Version = f.flagSet.Arg(0)
+	b.PreRelease = *f.pre
+	b.Promote = *f.promote
+	b.Metadata = *f.metadata
+	b.Changelog.Input = input
+	b.Changelog.Template = f.template
+	if *f.group {
+		b.Changelog.Sections = f.sections
+	}
+	return b
 }
 
-func (f *bumptagArgs) parse() error {
-	f.flagSet.Usage = f.usage
-	return f.flagSet.Parse(os.Args[1:])
+func (f *bumptagArgs) newTagger(repo *bumptag.Repo) *bumptag.Tagger {
+	t := bumptag.NewTagger(repo)
+	t.Sign = *f.sign
+	t.SignKey = *f.signKey
+	t.Remote = *f.remote
+	t.AutoPush = *f.autoPush
+	t.FixModulePath = *f.fixModulePath
+	t.ChangelogFile = *f.changelogFile
+	t.BumpFiles = f.bumpFiles
+	t.BumpMessage = *f.bumpMessage
+	t.Replacements = f.replacements
+	t.Hooks = f.hooks
+	t.Branches = f.branches
+	t.SkipChecks = f.skipCheckNames()
+	return t
 }
 
-func newBumptagArgs() *bumptagArgs {
-	flagSet := flag.NewFlagSet("Bumptag", flag.ExitOnError)
-	return &bumptagArgs{
-		flagSet:  flagSet,
-		edit:     createFlag(flagSet, "edit", "e", false, "Edit an annotation"),
-		dryRun:   createFlag(flagSet, "dry-run", "r", false, "Prints an annotation for the new tag"),
-		silent:   createFlag(flagSet, "silent", "s", false, "Do not show the created tag"),
-		autoPush: createFlag(flagSet, "auto-push", "a", false, "Push the created tag automatically"),
-		major:    createFlag(flagSet, "major", "m", false, "Increment the MAJOR version"),
-		minor:    createFlag(flagSet, "minor", "n", false, "Increment the MINOR version (default)"),
-		patch:    createFlag(flagSet, "patch", "p", false, "Increment the PATCH version"),
-		version:  createFlag(flagSet, "version", "", false, "Show a version of the bumptag tool"),
-		findTag:  createFlag(flagSet, "find-tag", "", false, "Show the latest tag, can be useful for CI tools"),
+// skipCheckNames splits the comma separated names of the skipped checks.
+func (f *bumptagArgs) skipCheckNames() []string {
+	var names []string
+	for _, value := range f.skipChecks {
+		names = append(names, strings.Split(value, ",")...)
 	}
+	return names
 }
 
-func setTag(flagSet *flag.FlagSet, tag *semver.Version, args *bumptagArgs) {
-	if flagSet.NArg() > 0 {
-		if err := tag.Set(strings.TrimPrefix(flagSet.Arg(0), tagPrefix)); err != nil {
-			panic(err)
-		}
-	} else {
-		switch true {
-		case *args.major:
-			tag.BumpMajor()
-		case *args.minor:
-			tag.BumpMinor()
-		case *args.patch:
-			tag.BumpPatch()
-		default:
-			tag.BumpMinor()
-		}
+// newPublisher returns the publisher of the releases on the forge or nil if the releases are not enabled.
+// The assets are checked in advance, so the tag is not created if they are missing.
+func (f *bumptagArgs) newPublisher(repo *bumptag.Repo) (*bumptag.Publisher, error) {
+	if len(*f.release) == 0 || *f.dryRun {
+		return nil, nil
 	}
-}
-
-func panicIfError(err error) {
+	if !*f.autoPush {
+		return nil, flagError("--release requires --auto-push")
+	}
+	p, err := bumptag.NewPublisher(repo, *f.release)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	p.URL = *f.releaseURL
+	p.Project = f.project
+	p.Assets = f.releaseAssets
+	if _, err := p.AssetFiles(); err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
-func openEditor(filename string) error {
-	editor := os.Getenv("EDITOR")
+func openEditor(editor, filename string) error {
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
 	if editor == "" {
 		editor = defaultEditor
 	}
@@ -333,7 +603,7 @@ func openEditor(filename string) error {
 	return cmd.Run()
 }
 
-func edit(annotation string) (string, error) {
+func edit(editor, annotation string) (string, error) {
 	file, err := ioutil.TempFile(os.TempDir(), "*")
 	if err != nil {
 		return "", err
@@ -348,7 +618,7 @@ func edit(annotation string) (string, error) {
 		return "", err
 	}
 
-	if err := openEditor(filename); err != nil {
+	if err := openEditor(editor, filename); err != nil {
 		return "", err
 	}
 	data, err := ioutil.ReadFile(filename)
@@ -359,62 +629,123 @@ func edit(annotation string) (string, error) {
 	return string(data), nil
 }
 
-func main() {
-	args := newBumptagArgs()
-	panicIfError(args.parse())
+// interrupt is the function called on Ctrl-C before the repository is restored, see onInterrupt.
+var interrupt struct {
+	sync.Mutex
+	fn func()
+}
 
-	if *args.version {
-		fmt.Print(version)
-		return
+// onInterrupt sets the function to call on Ctrl-C, nil removes it.
+func onInterrupt(fn func()) {
+	interrupt.Lock()
+	defer interrupt.Unlock()
+	interrupt.fn = fn
+}
+
+// setUp prepares the repository and restores it on Ctrl-C, after the function set by onInterrupt.
+func setUp(repo *bumptag.Repo) (func(), error) {
+	restore, err := repo.Backend.Setup()
+	if err != nil {
+		return nil, err
 	}
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt)
+	go func() {
+		<-signalChan
+		interrupt.Lock()
+		if interrupt.fn != nil {
+			interrupt.fn()
+		}
+		restore()
+		exit(exitInterrupted)
+	}()
 
-	tearDownGPG, err := setUpGPG()
-	panicIfError(err)
-	defer tearDownGPG()
+	return func() {
+		signal.Stop(signalChan)
+		restore()
+	}, nil
+}
 
-	tag, currentTagName, err := findTag()
-	panicIfError(err)
+// exit terminates the program, it is replaced in the tests.
+var exit = os.Exit
 
-	if *args.findTag {
-		fmt.Print(currentTagName)
-		return
+// run executes the command and returns the error instead of exiting, so the deferred functions are called.
+func run(args *bumptagArgs) error {
+	if *args.version {
+		fmt.Print(version)
+		return nil
+	}
+	if *args.ci {
+		if err := checkCI(); err != nil {
+			return err
+		}
 	}
 
-	changeLog, err := getChangeLog(currentTagName)
-	panicIfError(err)
-
-	setTag(args.flagSet, tag, args)
-	tagName := tagPrefix + tag.String()
-	annotation := makeAnnotation(changeLog, tagName)
+	repo, err := bumptag.OpenRepo(".", *args.backend)
+	if err != nil {
+		return err
+	}
+	repo.Warn = args.warn()
+	if *args.debug {
+		if cli, ok := repo.Backend.(*bumptag.CLIBackend); ok {
+			cli.SetTrace(os.Stderr)
+		} else {
+			fmt.Fprintln(os.Stderr, "Warning: the go backend runs no git commands to trace")
+		}
+	}
+	tearDown, err := setUp(repo)
+	if err != nil {
+		return err
+	}
+	defer tearDown()
 
-	if *args.edit {
-		annotation, err = edit(annotation)
-		panicIfError(err)
+	cfg, err := repo.LoadConfig()
+	if err != nil {
+		return err
 	}
+	args.applyConfig(cfg)
+	if err := args.loadTemplate(cfg); err != nil {
+		return err
+	}
+	repo.TagPrefix = *args.prefix
 
-	if *args.dryRun {
-		fmt.Println(annotation)
-		return
+	if *args.allModules {
+		return releaseAllModules(args, repo)
 	}
 
-	sign := gitConfigBool("commit.gpgsign", false)
-	panicIfError(createTag(tagName, annotation, sign))
+	m := repo.RootModule()
+	if len(*args.module) > 0 {
+		if m, err = repo.Module(*args.module); err != nil {
+			return err
+		}
+	}
 
-	if *args.autoPush {
-		remote, err := getRemote()
-		panicIfError(err)
-		panicIfError(pushTag(remote, tagName))
-		if !*args.silent {
-			fmt.Printf(
-				"The tag '%s' has been pushed to the remote '%s'",
-				tagName,
-				remote,
-			)
+	switch {
+	case args.command == commandSuggest:
+		s, err := repo.Suggest(m, *args.strategy)
+		if err != nil {
+			return err
 		}
+		fmt.Println(s)
+		return nil
+	case args.command == commandChangelog:
+		return changelog(args, repo, m)
+	case *args.findTag:
+		return findTag(args, repo, m)
+	case *args.check:
+		return checkFiles(args, repo, m)
+	}
+	return release(args, repo, m)
+}
+
+func main() {
+	args := newBumptagArgs()
+	err := args.parse()
+	if err == nil {
+		err = run(args)
 	}
-	if !*args.silent {
-		output, err := showTag(tagName)
-		panicIfError(err)
-		fmt.Println(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		exit(exitCode(err))
 	}
 }
@@ -0,0 +1,114 @@
+package bumptag
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// The names of the backends.
+const (
+	BackendAuto = "auto"
+	BackendCLI  = "cli"
+	BackendGo   = "go"
+)
+
+// ValidateBackend returns an error if the name is not a backend.
+func ValidateBackend(name string) error {
+	switch name {
+	case BackendAuto, BackendCLI, BackendGo:
+		return nil
+	}
+	return fmt.Errorf("unknown backend '%s'", name)
+}
+
+// Backend is an interface to the git repository.
+// The tag patterns are matched like git does: `*` matches any string including `/`.
+type Backend interface {
+	// Setup prepares the repository and returns a function to restore it.
+	Setup() (func(), error)
+	// Root returns the top-level directory of the working tree.
+	Root() (string, error)
+	// Config returns the value of the config option, e.g. `commit.gpgsign`.
+	Config(name string) (string, error)
+	// Tags returns the names of the tags matching the pattern, all tags if the pattern is empty.
+	Tags(pattern string) ([]string, error)
+	// MergedTags returns the names of the tags reachable from HEAD.
+	MergedTags() ([]string, error)
+	// DescribeTag returns the nearest tag reachable from HEAD matching the pattern and not matching the exclude pattern,
+	// the empty patterns are ignored.
+	DescribeTag(match, exclude string) (string, error)
+	// PointsAt returns the names of the tags pointing at the same commit as the given tag.
+	PointsAt(tagName string) ([]string, error)
+	// Log returns the non-merge commits since the given tag, or all commits if the tag is empty,
+	// until the given revision (HEAD if empty) touching the path (the whole repository if empty)
+	// except the excluded paths. The paths are relative to the root.
+	Log(since, until, path string, excludes []string) ([]*Commit, error)
+	// ReadTag returns the date and the body of the annotation of the tag without the subject and the signature,
+	// the date of the commit and the empty body for a lightweight tag.
+	ReadTag(tagName string) (*Tag, error)
+	// ShortHash returns the abbreviated commit hash of the revision.
+	ShortHash(rev string) (string, error)
+	// ReadFile returns the content of the file at the revision, the path is relative to the root.
+	// The error wraps fs.ErrNotExist if the revision has no such file.
+	ReadFile(rev, path string) (string, error)
+	// FindFiles returns the tracked files with the given name, the paths are relative to the root.
+	FindFiles(name string) ([]string, error)
+	// ChangedFiles returns the tracked files with the uncommitted changes, the paths are relative to the root.
+	ChangedFiles() ([]string, error)
+	// Checkout creates a temporary copy of the working tree at the revision and returns its directory
+	// and a function to remove it.
+	Checkout(rev string) (string, func(), error)
+	// Commit commits the files with the message, the paths are relative to the root,
+	// the other staged files are not committed and stay staged.
+	Commit(message string, files []string) error
+	// CreateTag creates an annotated tag at HEAD, the signed tag is signed by the key,
+	// the default signing key of git if empty.
+	CreateTag(tagName, annotation string, sign bool, signKey string) error
+	// VerifyTag verifies the signature of the tag.
+	VerifyTag(tagName string) error
+	// DeleteTag deletes the local tag.
+	DeleteTag(tagName string) error
+	// Reset resets HEAD and the index to the revision, the files changed by the reset commits are restored,
+	// the other uncommitted changes are kept.
+	Reset(rev string) error
+	// ShowTag returns a human readable description of the tag.
+	ShowTag(tagName string) (string, error)
+	// Remote returns the remote of the current branch.
+	Remote() (string, error)
+	// Branch returns the name of the current branch, empty if HEAD is detached.
+	Branch() (string, error)
+	// Behind fetches the upstream of the current branch and returns the number of its commits missing in HEAD,
+	// zero if the branch has no upstream.
+	Behind() (int, error)
+	// HasRemoteTag checks if the remote has the tag.
+	HasRemoteTag(remote, tagName string) (bool, error)
+	// PushTag pushes the tag to the remote.
+	PushTag(remote, tagName string) error
+}
+
+// Tag is an existing tag.
+type Tag struct {
+	Name string
+	// Date is the date of the annotated tag or the commit date of the lightweight tag.
+	Date time.Time
+	// Body is the annotation without the subject line, usually the change log.
+	Body string
+}
+
+// OpenBackend returns the backend by its name for the repository in the directory,
+// the auto backend runs the git binary if it is installed and reads the repository directly otherwise.
+func OpenBackend(name, dir string) (Backend, error) {
+	switch name {
+	case BackendAuto:
+		if _, err := exec.LookPath("git"); err != nil {
+			return OpenGoGitBackend(dir)
+		}
+		return NewCLIBackend(dir), nil
+	case BackendCLI:
+		return NewCLIBackend(dir), nil
+	case BackendGo:
+		return OpenGoGitBackend(dir)
+	}
+	return nil, fmt.Errorf("unknown backend '%s'", name)
+}
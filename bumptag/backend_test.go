@@ -0,0 +1,31 @@
+package bumptag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenBackend(t *testing.T) {
+	b, err := OpenBackend(BackendCLI, ".")
+	assert.NoError(t, err)
+	assert.IsType(t, &CLIBackend{}, b)
+
+	b, err = OpenBackend(BackendAuto, ".")
+	assert.NoError(t, err)
+	assert.IsType(t, &CLIBackend{}, b)
+
+	b, err = OpenBackend(BackendGo, ".")
+	assert.NoError(t, err)
+	assert.IsType(t, &GoGitBackend{}, b)
+
+	t.Setenv("PATH", "")
+	b, err = OpenBackend(BackendAuto, ".")
+	assert.NoError(t, err)
+	assert.IsType(t, &GoGitBackend{}, b)
+
+	_, err = OpenBackend("test-backend", ".")
+	assert.EqualError(t, err, "unknown backend 'test-backend'")
+	assert.NoError(t, ValidateBackend(BackendGo))
+	assert.EqualError(t, ValidateBackend("test-backend"), "unknown backend 'test-backend'")
+}
@@ -0,0 +1,137 @@
+package bumptag
+
+import (
+	"strings"
+	"time"
+)
+
+// Release is a new tag of a module.
+type Release struct {
+	Module *Module
+	// PreviousTag is the name of the latest tag, empty if the module has no tags.
+	PreviousTag     string
+	PreviousVersion Version
+	Version         *Version
+	TagName         string
+	Level           BumpLevel
+	// Date is the time of the release.
+	Date time.Time
+	// Reason explains the bump level detected from the Conventional Commits, empty if the level is not detected.
+	Reason string
+	// Commits are the commits of the module since the previous tag.
+	Commits []*Commit
+	// ChangeLog is the change log built from the commits or read from the input.
+	ChangeLog  string
+	Annotation string
+}
+
+// Bumper calculates the next version of a module.
+type Bumper struct {
+	Repo *Repo
+	// Strategy is the strategy to find the latest tag, see StrategyDescribe.
+	Strategy string
+	Level    BumpLevel
+	// ExplicitLevel means the level is requested by the user, so it wins over the detected one
+	// and increments the version of a pre-release.
+	ExplicitLevel bool
+	// Auto detects the bump level from the Conventional Commits messages.
+	Auto bool
+	// Version is the exact version of the new tag, the prefix of the module is optional.
+	Version string
+	// PreRelease is the channel of a new pre-release, e.g. `rc`.
+	PreRelease string
+	// Promote turns the latest pre-release into the final release.
+	Promote bool
+	// Metadata is the build metadata with the placeholders, see Repo.ExpandMetadata.
+	Metadata  string
+	Changelog ChangelogBuilder
+
+	now func() time.Time
+}
+
+// NewBumper returns a bumper of the repository incrementing the MINOR version of the latest tag found by describe.
+func NewBumper(repo *Repo) *Bumper {
+	return &Bumper{
+		Repo:     repo,
+		Strategy: StrategyDescribe,
+		Level:    BumpMinor,
+		now:      time.Now,
+	}
+}
+
+func (b *Bumper) isAutoBump() bool {
+	return b.Auto && len(b.Version) == 0 && !b.Promote && !b.ExplicitLevel
+}
+
+func (b *Bumper) setVersion(m *Module, v *Version, level BumpLevel) error {
+	switch {
+	case len(b.Version) > 0:
+		exact, err := NewVersion(strings.TrimPrefix(b.Version, m.Prefix))
+		if err != nil {
+			return err
+		}
+		*v = *exact
+		return nil
+	case b.Promote:
+		return v.Promote()
+	case len(b.PreRelease) > 0:
+		return b.Repo.setPreRelease(m, v, b.PreRelease, level, b.ExplicitLevel)
+	}
+	v.Bump(level)
+	return nil
+}
+
+// Prepare finds the latest tag of the module and returns the next release with the change log since that tag,
+// the change log of a final release after the pre-releases starts at the previous final release.
+func (b *Bumper) Prepare(m *Module) (*Release, error) {
+	v, currentTagName, err := b.Repo.FindTag(m, b.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now
+	if b.now != nil {
+		now = b.now
+	}
+	r := &Release{
+		Module:          m,
+		PreviousTag:     currentTagName,
+		PreviousVersion: *v,
+		Version:         v,
+		Level:           b.Level,
+		Date:            now(),
+	}
+
+	if r.Commits, err = b.Repo.Commits(currentTagName, m); err != nil {
+		return nil, err
+	}
+	if b.isAutoBump() {
+		r.Level, r.Reason = AutoBumpLevel(v, r.Commits)
+	}
+	if err := b.setVersion(m, v, r.Level); err != nil {
+		return nil, err
+	}
+	if len(r.PreviousVersion.PreRelease) > 0 && len(v.PreRelease) == 0 {
+		// the final release contains the commits of its pre-releases
+		since, err := b.Repo.lastReleaseTag(m, v)
+		if err != nil {
+			return nil, err
+		}
+		if r.Commits, err = b.Repo.Commits(since, m); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.ChangeLog, err = b.Changelog.Build(r.Commits); err != nil {
+		return nil, err
+	}
+	if len(b.Metadata) > 0 {
+		if v.Metadata, err = b.Repo.ExpandMetadata(b.Metadata, r.Date); err != nil {
+			return nil, err
+		}
+	}
+	r.TagName = m.TagName(v)
+	if r.Annotation, err = b.Changelog.Annotation(r, r.ChangeLog); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
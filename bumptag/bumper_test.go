@@ -0,0 +1,79 @@
+package bumptag
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBumper(t *testing.T) {
+	_, cli := mockGit(t)
+	b := NewBumper(NewRepo(cli))
+	assert.Equal(t, StrategyDescribe, b.Strategy)
+	assert.Equal(t, BumpMinor, b.Level)
+	assert.False(t, b.isAutoBump())
+
+	b.Auto = true
+	assert.True(t, b.isAutoBump())
+	b.Version = "v1.0.0"
+	assert.False(t, b.isAutoBump())
+}
+
+func TestBumperPrepare(t *testing.T) {
+	prepareCommit, repo := prepareGit(t)
+	b := NewBumper(repo)
+	b.now = func() time.Time {
+		return time.Date(2020, 5, 17, 23, 0, 0, 0, time.UTC)
+	}
+
+	r, err := b.Prepare(RootModule())
+	assert.NoError(t, err)
+	assert.Empty(t, r.PreviousTag)
+	assert.Equal(t, "v0.1.0", r.TagName)
+	assert.Len(t, r.Commits, 1)
+	assert.Contains(t, r.Annotation, "Bump version v0.1.0\n\n* ")
+	assert.Contains(t, r.Annotation, "commit-#0")
+
+	_, err = git("", "tag", "v1.1.1")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, err = git("", "commit", "--allow-empty", "-m", "feat: test feature")
+	assert.NoError(t, err)
+
+	b.Auto = true
+	r, err = b.Prepare(RootModule())
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.1.1", r.PreviousTag)
+	assert.Equal(t, "1.1.1", r.PreviousVersion.String())
+	assert.Equal(t, "v1.2.0", r.TagName)
+	assert.Equal(t, BumpMinor, r.Level)
+	assert.Contains(t, r.Reason, "new feature in")
+	assert.Len(t, r.Commits, 2)
+
+	b.Auto = false
+	b.Level = BumpPatch
+	b.PreRelease = "rc"
+	b.Metadata = "{date}"
+	r, err = b.Prepare(RootModule())
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.1.2-rc.1+20200517", r.TagName)
+
+	b.Version = "v3.0.0"
+	b.Changelog.Input = strings.NewReader("test-changelog")
+	r, err = b.Prepare(RootModule())
+	assert.NoError(t, err)
+	assert.Equal(t, "v3.0.0+20200517", r.TagName)
+	assert.Equal(t, "Bump version v3.0.0+20200517\n\ntest-changelog", r.Annotation)
+
+	b.Version = ""
+	b.Promote = true
+	_, err = b.Prepare(RootModule())
+	assert.EqualError(t, err, "invalid version: the version '1.1.1' is not a pre-release")
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+
+	b.Strategy = "test-strategy"
+	_, err = b.Prepare(RootModule())
+	assert.EqualError(t, err, "unknown strategy 'test-strategy'")
+}
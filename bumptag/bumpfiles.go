@@ -0,0 +1,342 @@
+package bumptag
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultBumpMessage is the template of the message of the commit of the version files.
+const DefaultBumpMessage = "Bump version to {{.TagName}}"
+
+// BumpVersion returns the content of the version file with the version replaced, the format is detected
+// by the name of the file:
+//
+//	VERSION         the whole content
+//	package.json    the top level "version" field
+//	Cargo.toml      the version of the [package] or [workspace.package] section
+//	pyproject.toml  the version of the [project] or [tool.poetry] section
+//	Chart.yaml      the version and appVersion fields
+//	*.go            the package level `Version` or `version` constant or variable of the string literal
+func BumpVersion(filename string, data []byte, version string) ([]byte, error) {
+	var res []byte
+	var err error
+	switch base := filepath.Base(filename); {
+	case base == "VERSION":
+		return []byte(version + "\n"), nil
+	case base == "package.json":
+		res, err = bumpJSONVersion(data, version)
+	case base == "Cargo.toml":
+		res, err = bumpTOMLVersion(data, version, "package", "workspace.package")
+	case base == "pyproject.toml":
+		res, err = bumpTOMLVersion(data, version, "project", "tool.poetry")
+	case base == "Chart.yaml":
+		res, err = bumpYAMLVersion(data, version, "version", "appVersion")
+	case strings.HasSuffix(base, ".go"):
+		res, err = bumpGoVersion(filename, data, version)
+	default:
+		return nil, fmt.Errorf("unknown version file '%s'", filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot update the version in '%s': %w", filename, err)
+	}
+	return res, nil
+}
+
+// errNoVersion means the version file has no version to replace.
+var errNoVersion = errors.New("the version is not found")
+
+// replaceRange replaces the bytes between start and end.
+func replaceRange(data []byte, start, end int, value string) []byte {
+	res := make([]byte, 0, len(data)-(end-start)+len(value))
+	res = append(res, data[:start]...)
+	res = append(res, value...)
+	return append(res, data[end:]...)
+}
+
+// bumpJSONVersion replaces the top level "version" field keeping the formatting of the file.
+func bumpJSONVersion(data []byte, version string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, errors.New("the content is not a JSON object")
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		start := int(dec.InputOffset())
+		// the nested objects are skipped as the raw values
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if key != "version" {
+			continue
+		}
+		if !bytes.HasPrefix(value, []byte(`"`)) {
+			return nil, errors.New("the version is not a string")
+		}
+		end := int(dec.InputOffset())
+		start += bytes.IndexByte(data[start:end], '"')
+		return replaceRange(data, start, end, strconv.Quote(version)), nil
+	}
+	return nil, errNoVersion
+}
+
+var (
+	tomlSectionRe = regexp.MustCompile(`^\s*\[\[?\s*([^\]\s]+)\s*\]`)
+	tomlVersionRe = regexp.MustCompile(`^\s*version\s*=\s*["']([^"']*)["']`)
+)
+
+// bumpTOMLVersion replaces the version field of the first found section.
+func bumpTOMLVersion(data []byte, version string, sections ...string) ([]byte, error) {
+	var section string
+	offset := 0
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if m := tomlSectionRe.FindStringSubmatch(line); m != nil {
+			section = m[1]
+		} else if m := tomlVersionRe.FindStringSubmatchIndex(line); m != nil && slices.Contains(sections, section) {
+			return replaceRange(data, offset+m[2], offset+m[3], version), nil
+		}
+		offset += len(line)
+	}
+	return nil, fmt.Errorf("%w in the [%s] section", errNoVersion, strings.Join(sections, "] or ["))
+}
+
+// bumpYAMLVersion replaces the top level fields, the first field is required.
+func bumpYAMLVersion(data []byte, version string, fields ...string) ([]byte, error) {
+	res := data
+	for i, field := range fields {
+		re := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(field) + `:\s*["']?([^"'\s#]*)`)
+		m := re.FindSubmatchIndex(res)
+		if m == nil {
+			if i == 0 {
+				return nil, fmt.Errorf("%w in the %s field", errNoVersion, field)
+			}
+			continue
+		}
+		res = replaceRange(res, m[2], m[3], version)
+	}
+	return res, nil
+}
+
+// bumpGoVersion replaces the string literals of the package level `Version` and `version` constants
+// and variables, e.g. `const Version = "1.2.0"` or `var version = "1.2.0"`.
+func bumpGoVersion(filename string, data []byte, version string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, data, 0)
+	if err != nil {
+		return nil, err
+	}
+	var literals []*ast.BasicLit
+	for _, decl := range f.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && (gen.Tok == token.CONST || gen.Tok == token.VAR) {
+			literals = append(literals, versionLiterals(gen)...)
+		}
+	}
+	if len(literals) == 0 {
+		return nil, fmt.Errorf("%w in the Version constant or variable", errNoVersion)
+	}
+	res := data
+	// the literals are replaced from the end, so the offsets of the previous ones are kept
+	for i := len(literals) - 1; i >= 0; i-- {
+		lit := literals[i]
+		res = replaceRange(res, fset.Position(lit.Pos()).Offset, fset.Position(lit.End()).Offset, strconv.Quote(version))
+	}
+	return res, nil
+}
+
+// versionLiterals returns the string literals of the `Version` and `version` names of the declaration.
+func versionLiterals(gen *ast.GenDecl) []*ast.BasicLit {
+	var res []*ast.BasicLit
+	for _, spec := range gen.Specs {
+		spec := spec.(*ast.ValueSpec)
+		for i, name := range spec.Names {
+			if (name.Name != "Version" && name.Name != "version") || i >= len(spec.Values) {
+				continue
+			}
+			if lit, ok := spec.Values[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				res = append(res, lit)
+			}
+		}
+	}
+	return res
+}
+
+// bumpedFile is a version file with the updated content.
+type bumpedFile struct {
+	filename string
+	rel      string
+	data     []byte
+	content  []byte
+}
+
+// bumpFiles updates the version in the version files and by the replacements and commits the changed files
+// together with the files changed by the pre-bump hook with the message,
+// see BumpVersion, Replacement and DefaultBumpMessage.
+func (t *Tagger) bumpFiles(r *Release, hooked []string) error {
+	message, err := t.bumpMessage(r)
+	if err != nil {
+		return err
+	}
+	files, err := t.bumpedFiles(r, hooked)
+	if err != nil || len(files)+len(hooked) == 0 {
+		return err
+	}
+	rels := slices.Clone(hooked)
+	for _, f := range files {
+		t.record(fmt.Sprintf("the changes of '%s'", f.rel), func() error {
+			return writeFile(f.filename, string(f.data))
+		})
+		if err := writeFile(f.filename, string(f.content)); err != nil {
+			return err
+		}
+		if !slices.Contains(rels, f.rel) {
+			rels = append(rels, f.rel)
+		}
+	}
+	return t.commit(message, rels)
+}
+
+// bumpMessage renders the message of the commit of the version files.
+func (t *Tagger) bumpMessage(r *Release) (string, error) {
+	text := t.BumpMessage
+	if len(text) == 0 {
+		text = DefaultBumpMessage
+	}
+	tmpl, err := ParseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var message strings.Builder
+	if err := tmpl.Execute(&message, r); err != nil {
+		return "", err
+	}
+	return message.String(), nil
+}
+
+// fileSet reads the files to update, every file is read once.
+type fileSet struct {
+	repo  *Repo
+	files []*bumpedFile
+	index map[string]*bumpedFile
+}
+
+func (s *fileSet) read(name string) (*bumpedFile, error) {
+	filename, rel, err := s.repo.repoPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := s.index[rel]; ok {
+		return f, nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	f := &bumpedFile{filename: filename, rel: rel, data: data, content: data}
+	s.index[rel] = f
+	s.files = append(s.files, f)
+	return f, nil
+}
+
+// replace applies the replacement to its files.
+func (s *fileSet) replace(rp *Replacement, root string, r *Release) error {
+	names, err := rp.files(root)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		f, err := s.read(name)
+		if err != nil {
+			return err
+		}
+		if f.content, err = rp.Apply(f.content, r); err != nil {
+			return fmt.Errorf("cannot update the version in '%s': %w", f.rel, err)
+		}
+	}
+	return nil
+}
+
+// versionFiles returns the version files and the files of the replacements with the content updated
+// to the version of the release, a file is updated by all its rules in order.
+func (t *Tagger) versionFiles(r *Release) ([]*bumpedFile, error) {
+	s := &fileSet{repo: t.Repo, index: make(map[string]*bumpedFile)}
+	for _, name := range t.BumpFiles {
+		f, err := s.read(name)
+		if err != nil {
+			return nil, err
+		}
+		if f.content, err = BumpVersion(f.rel, f.content, r.Version.String()); err != nil {
+			return nil, err
+		}
+	}
+	if len(t.Replacements) == 0 {
+		return s.files, nil
+	}
+	root, err := t.Repo.Backend.Root()
+	if err != nil {
+		return nil, err
+	}
+	for _, rp := range t.Replacements {
+		if err := s.replace(rp, root, r); err != nil {
+			return nil, err
+		}
+	}
+	return s.files, nil
+}
+
+// changedFiles returns the files with the updated content.
+func changedFiles(files []*bumpedFile) []*bumpedFile {
+	var res []*bumpedFile
+	for _, f := range files {
+		if !bytes.Equal(f.data, f.content) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// bumpedFiles returns the version files changed by the new version, all files are checked before writing any.
+// The files changed by the pre-bump hook are not required to be clean.
+func (t *Tagger) bumpedFiles(r *Release, hooked []string) ([]*bumpedFile, error) {
+	files, err := t.versionFiles(r)
+	if err != nil {
+		return nil, err
+	}
+	rels := make([]string, 0, len(files))
+	for _, f := range files {
+		if !slices.Contains(hooked, f.rel) {
+			rels = append(rels, f.rel)
+		}
+	}
+	if err := t.guardCleanTree(rels...); err != nil {
+		return nil, err
+	}
+	return changedFiles(files), nil
+}
+
+// OutdatedFiles returns the version files and the files of the replacements not matching the version
+// of the release, e.g. of the latest tag. The paths are relative to the root of the repository.
+func (t *Tagger) OutdatedFiles(r *Release) ([]string, error) {
+	files, err := t.versionFiles(r)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, f := range changedFiles(files) {
+		res = append(res, f.rel)
+	}
+	return res, nil
+}
@@ -0,0 +1,184 @@
+package bumptag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBumpVersion(t *testing.T) {
+	cases := []struct {
+		filename string
+		content  string
+		expected string
+	}{
+		{"VERSION", "1.1.0\n", "1.2.0\n"},
+		{
+			"package.json",
+			"{\n  \"name\": \"test\",\n  \"dependencies\": {\"version\": \"1.0.0\"},\n  \"version\": \"1.1.0\"\n}\n",
+			"{\n  \"name\": \"test\",\n  \"dependencies\": {\"version\": \"1.0.0\"},\n  \"version\": \"1.2.0\"\n}\n",
+		},
+		{
+			"Cargo.toml",
+			"[dependencies]\nversion = \"1.0.0\"\n\n[package]\nname = \"test\"\nversion = \"1.1.0\" # the version\n",
+			"[dependencies]\nversion = \"1.0.0\"\n\n[package]\nname = \"test\"\nversion = \"1.2.0\" # the version\n",
+		},
+		{
+			"sub/pyproject.toml",
+			"[tool.poetry]\nname = 'test'\nversion = '1.1.0'\n",
+			"[tool.poetry]\nname = 'test'\nversion = '1.2.0'\n",
+		},
+		{
+			"Chart.yaml",
+			"apiVersion: v2\nversion: 1.1.0\nappVersion: \"1.1.0\"\ndependencies:\n  - version: 1.0.0\n",
+			"apiVersion: v2\nversion: 1.2.0\nappVersion: \"1.2.0\"\ndependencies:\n  - version: 1.0.0\n",
+		},
+		{
+			"main.go",
+			"package main\n\nvar version = \"0.0.0\"\n\nconst (\n\tName    = \"test\"\n\tVersion = `1.1.0`\n)\n",
+			"package main\n\nvar version = \"1.2.0\"\n\nconst (\n\tName    = \"test\"\n\tVersion = \"1.2.0\"\n)\n",
+		},
+	}
+	for _, c := range cases {
+		data, err := BumpVersion(c.filename, []byte(c.content), "1.2.0")
+		assert.NoError(t, err, c.filename)
+		assert.Equal(t, c.expected, string(data), c.filename)
+	}
+
+	errorCases := []struct {
+		filename string
+		content  string
+		message  string
+	}{
+		{"setup.py", "", "unknown version file 'setup.py'"},
+		{"package.json", "{\"name\": \"test\"}", "cannot update the version in 'package.json': the version is not found"},
+		{
+			"package.json",
+			"{\"version\": {}}",
+			"cannot update the version in 'package.json': the version is not a string",
+		},
+		{
+			"Cargo.toml",
+			"[package]\nversion.workspace = true\n",
+			"cannot update the version in 'Cargo.toml': " +
+				"the version is not found in the [package] or [workspace.package] section",
+		},
+		{
+			"Chart.yaml",
+			"name: test\n",
+			"cannot update the version in 'Chart.yaml': the version is not found in the version field",
+		},
+		{
+			"main.go",
+			"package main\n\nfunc main() {\n\tversion := \"1.1.0\"\n\t_ = version\n}\n",
+			"cannot update the version in 'main.go': the version is not found in the Version constant or variable",
+		},
+	}
+	for _, c := range errorCases {
+		_, err := BumpVersion(c.filename, []byte(c.content), "1.2.0")
+		assert.EqualError(t, err, c.message, c.filename)
+	}
+}
+
+func TestTaggerBumpFiles(t *testing.T) {
+	_, repo := prepareGit(t)
+	assert.NoError(t, os.WriteFile("VERSION", []byte("1.1.0\n"), 0o600))
+	assert.NoError(t, os.WriteFile("version.go", []byte("package test\n\nconst Version = \"1.1.0\"\n"), 0o600))
+	_, err := git("", "add", "VERSION", "version.go")
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "Add the version files")
+	assert.NoError(t, err)
+
+	tagger := &Tagger{
+		Repo:        repo,
+		BumpFiles:   []string{"VERSION", "version.go"},
+		BumpMessage: "chore: release {{.TagName}}",
+		SkipChecks:  []string{CheckBehind},
+	}
+	r := &Release{Module: RootModule(), Version: mustVersion("1.2.0"), TagName: "v1.2.0", Annotation: "test"}
+	assert.NoError(t, tagger.Tag(r))
+	output, err := git("", "log", "-1", "--stat", "--format=%s%d")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "chore: release v1.2.0 (HEAD -> master, tag: v1.2.0)")
+	assert.Contains(t, output, "VERSION")
+	assert.Contains(t, output, "version.go")
+	data, err := os.ReadFile("version.go")
+	assert.NoError(t, err)
+	assert.Equal(t, "package test\n\nconst Version = \"1.2.0\"\n", string(data))
+
+	undone, err := tagger.Rollback()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"the tag 'v1.2.0'",
+		"the commit 'chore: release v1.2.0'",
+		"the changes of 'version.go'",
+		"the changes of 'VERSION'",
+	}, undone)
+	data, err = os.ReadFile("VERSION")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.0\n", string(data))
+	output, err = git("", "log", "-1", "--pretty=%s")
+	assert.NoError(t, err)
+	assert.Equal(t, "Add the version files", output)
+
+	tagger.BumpFiles = append(tagger.BumpFiles, "setup.py")
+	assert.NoError(t, os.WriteFile("setup.py", nil, 0o600))
+	assert.EqualError(t, tagger.Tag(r), "unknown version file 'setup.py'")
+	data, err = os.ReadFile("VERSION")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.0\n", string(data), "no file is changed if any of them is invalid")
+}
+
+func TestTaggerReplacements(t *testing.T) {
+	_, repo := prepareGit(t)
+	assert.NoError(t, os.MkdirAll("deploy", 0o700))
+	assert.NoError(t, os.WriteFile("VERSION", []byte("1.1.0\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join("deploy", "values.yaml"), []byte("image:\n  tag: v1.1.0\n"), 0o600))
+	assert.NoError(t, os.WriteFile("Dockerfile", []byte("ARG VERSION=1.1.0\n"), 0o600))
+	_, err := git("", "add", "VERSION", "deploy", "Dockerfile")
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "Add the version files")
+	assert.NoError(t, err)
+
+	values, err := NewReplacement("deploy/*.yaml", `(tag: )\S+`, "${1}{{.TagName}}")
+	assert.NoError(t, err)
+	docker, err := NewReplacement("Dockerfile", `(VERSION=)\S+`, "${1}{{.Version}}")
+	assert.NoError(t, err)
+	tagger := &Tagger{
+		Repo:         repo,
+		BumpFiles:    []string{"VERSION"},
+		Replacements: []*Replacement{values, docker},
+		SkipChecks:   []string{CheckBehind},
+	}
+	previous := &Release{Module: RootModule(), Version: mustVersion("1.1.0"), TagName: "v1.1.0"}
+	outdated, err := tagger.OutdatedFiles(previous)
+	assert.NoError(t, err)
+	assert.Empty(t, outdated)
+
+	r := &Release{Module: RootModule(), Version: mustVersion("1.2.0"), TagName: "v1.2.0", Annotation: "test"}
+	outdated, err = tagger.OutdatedFiles(r)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"VERSION", "deploy/values.yaml", "Dockerfile"}, outdated)
+
+	assert.NoError(t, tagger.Tag(r))
+	output, err := git("", "log", "-1", "--stat", "--format=%s%d")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "Bump version to v1.2.0 (HEAD -> master, tag: v1.2.0)")
+	assert.Contains(t, output, "3 files changed")
+	data, err := os.ReadFile(filepath.Join("deploy", "values.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "image:\n  tag: v1.2.0\n", string(data))
+	outdated, err = tagger.OutdatedFiles(r)
+	assert.NoError(t, err)
+	assert.Empty(t, outdated)
+
+	tagger.Replacements[1], err = NewReplacement("Dockerfile", `FROM (\S+)`, "{{.Version}}")
+	assert.NoError(t, err)
+	_, err = tagger.OutdatedFiles(r)
+	assert.EqualError(t, err, "cannot update the version in 'Dockerfile': the pattern 'FROM (\\S+)' matches nothing")
+	tagger.Replacements[1].File = "*.json"
+	_, err = tagger.OutdatedFiles(r)
+	assert.EqualError(t, err, "the replacement file pattern '*.json' matches no files")
+}
@@ -0,0 +1,170 @@
+package bumptag
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultAnnotationTemplate is the template of the annotation used if no template is configured.
+const DefaultAnnotationTemplate = "Bump version {{.TagName}}\n\n{{.ChangeLog}}"
+
+// TemplateFuncs are the helper functions available in the annotation templates.
+var TemplateFuncs = template.FuncMap{
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, repl, s string) string { return strings.ReplaceAll(s, old, repl) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	"join":       func(sep string, elems []string) string { return strings.Join(elems, sep) },
+	"indent":     indent,
+	"date":       func(layout string, t time.Time) string { return t.Format(layout) },
+	// conventional parses the commit message, see ParseConventionalCommit
+	"conventional": ParseConventionalCommit,
+}
+
+// indent prefixes all non-empty lines with the given number of spaces.
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if len(line) > 0 {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseTemplate parses the annotation template, the text/template syntax with TemplateFuncs is supported.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("annotation").Funcs(TemplateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// AnnotationData is the data of the annotation template.
+type AnnotationData struct {
+	*Release
+	// ChangeLog is the change log built from the commits or read from the input, see ChangelogBuilder.Build.
+	ChangeLog string
+}
+
+// The titles of the default sections of the change log.
+const (
+	SectionBreakingChanges = "Breaking Changes"
+	SectionFeatures        = "Features"
+	SectionBugFixes        = "Bug Fixes"
+	SectionPerformance     = "Performance"
+	SectionOther           = "Other"
+)
+
+// Section is a group of the commits in the change log.
+type Section struct {
+	Title string
+	// Pattern matches the commit message: the subject and the body separated by an empty line.
+	// The section collects all commits not matched by the previous sections if nil.
+	Pattern *regexp.Regexp
+}
+
+// DefaultSections returns the sections by the Conventional Commits types,
+// a commit is added to the first matching section.
+func DefaultSections() []*Section {
+	return []*Section{
+		{Title: SectionBreakingChanges, Pattern: regexp.MustCompile(`(?m)\A\w+(\([^()]*\))?!:|^BREAKING[ -]CHANGE:`)},
+		{Title: SectionFeatures, Pattern: regexp.MustCompile(`(?i)\Afeat(\([^()]*\))?:`)},
+		{Title: SectionBugFixes, Pattern: regexp.MustCompile(`(?i)\Afix(\([^()]*\))?:`)},
+		{Title: SectionPerformance, Pattern: regexp.MustCompile(`(?i)\Aperf(\([^()]*\))?:`)},
+		{Title: SectionOther},
+	}
+}
+
+func (s *Section) match(c *Commit) bool {
+	return s.Pattern == nil || s.Pattern.MatchString(c.Subject+"\n\n"+c.Body)
+}
+
+// entry returns the line of the commit in the section,
+// the type of a Conventional Commit is omitted and the scope is bold unless the section collects the rest of commits.
+func (s *Section) entry(c *Commit) string {
+	cc := ParseConventionalCommit(c)
+	if s.Pattern == nil || cc == nil {
+		return "* " + c.String()
+	}
+	if len(cc.Scope) > 0 {
+		return "* " + c.Hash + " **" + cc.Scope + ":** " + cc.Description
+	}
+	return "* " + c.Hash + " " + cc.Description
+}
+
+// ChangelogBuilder generates the change log and the annotation of a new tag.
+type ChangelogBuilder struct {
+	// Input is the source of the change log written by hand, the change log is generated from the commits if nil.
+	Input io.Reader
+	// Template is the template of the annotation, DefaultAnnotationTemplate is used if nil.
+	Template *template.Template
+	// Sections group the commits in the change log, see DefaultSections. The change log is a flat list if empty.
+	Sections []*Section
+}
+
+// Build returns the change log of the commits, one line per commit grouped by the sections.
+func (b *ChangelogBuilder) Build(commits []*Commit) (string, error) {
+	if b.Input != nil {
+		output, err := io.ReadAll(b.Input)
+		if err != nil {
+			return "", err
+		}
+		return string(output), nil
+	}
+	if len(b.Sections) > 0 {
+		return b.buildSections(commits), nil
+	}
+
+	var res []string
+	for _, c := range commits {
+		res = append(res, "* "+c.String())
+	}
+	return strings.Join(res, "\n"), nil
+}
+
+// buildSections returns the non-empty sections with the title line and one line per commit,
+// the commits matching no section are skipped.
+// The titles are not markdown headings, because git strips the lines starting with `#` from the annotations.
+func (b *ChangelogBuilder) buildSections(commits []*Commit) string {
+	entries := make([][]string, len(b.Sections))
+	for _, c := range commits {
+		for i, s := range b.Sections {
+			if s.match(c) {
+				entries[i] = append(entries[i], s.entry(c))
+				break
+			}
+		}
+	}
+
+	var res []string
+	for i, s := range b.Sections {
+		if len(entries[i]) > 0 {
+			res = append(res, s.Title+":\n"+strings.Join(entries[i], "\n"))
+		}
+	}
+	return strings.Join(res, "\n\n")
+}
+
+// Annotation renders the annotation of the release with the change log by the template.
+func (b *ChangelogBuilder) Annotation(r *Release, changeLog string) (string, error) {
+	tmpl := b.Template
+	if tmpl == nil {
+		var err error
+		if tmpl, err = ParseTemplate(DefaultAnnotationTemplate); err != nil {
+			return "", err
+		}
+	}
+	var output strings.Builder
+	if err := tmpl.Execute(&output, &AnnotationData{Release: r, ChangeLog: changeLog}); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
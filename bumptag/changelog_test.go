@@ -0,0 +1,124 @@
+package bumptag
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangelogBuilderBuild(t *testing.T) {
+	b := &ChangelogBuilder{}
+	output, err := b.Build([]*Commit{
+		{Hash: "abc1234", Subject: "feat: test", Body: "test body"},
+		{Hash: "def5678", Subject: "fix: test"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "* abc1234 feat: test\n* def5678 fix: test", output)
+
+	output, err = b.Build(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+
+	b.Input = strings.NewReader("test-stdin-changelog")
+	output, err = b.Build([]*Commit{{Hash: "abc1234", Subject: "feat: test"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "test-stdin-changelog", output)
+}
+
+func TestChangelogBuilderBuildSections(t *testing.T) {
+	b := &ChangelogBuilder{Sections: DefaultSections()}
+	output, err := b.Build([]*Commit{
+		{Hash: "1111111", Subject: "feat(cli): add --group"},
+		{Hash: "2222222", Subject: "Update README.md"},
+		{Hash: "3333333", Subject: "fix: test"},
+		{Hash: "4444444", Subject: "feat(api)!: drop the old API"},
+		{Hash: "5555555", Subject: "perf: test", Body: "BREAKING CHANGE: test"},
+		{Hash: "6666666", Subject: "Perf: faster log"},
+		{Hash: "7777777", Subject: "docs(readme): test"},
+		{Hash: "8888888", Subject: "feat: test"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `Breaking Changes:
+* 4444444 **api:** drop the old API
+* 5555555 test
+
+Features:
+* 1111111 **cli:** add --group
+* 8888888 test
+
+Bug Fixes:
+* 3333333 test
+
+Performance:
+* 6666666 faster log
+
+Other:
+* 2222222 Update README.md
+* 7777777 docs(readme): test`, output)
+
+	b.Sections = []*Section{{Title: "Added", Pattern: regexp.MustCompile(`^Add`)}}
+	output, err = b.Build([]*Commit{{Hash: "1111111", Subject: "Add test"}, {Hash: "2222222", Subject: "Fix test"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Added:\n* 1111111 Add test", output)
+
+	output, err = b.Build(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+}
+
+func TestChangelogBuilderAnnotation(t *testing.T) {
+	b := &ChangelogBuilder{}
+	r := &Release{TagName: "test-tag"}
+	output, err := b.Annotation(r, "test-changelog")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bump version test-tag\n\ntest-changelog", output)
+
+	b.Template, err = ParseTemplate(`{{.Module}} {{.Version.Major}}.{{.Version.Minor}} ({{date "2006-01-02" .Date}}) ` +
+		`since {{.PreviousTag}}
+{{range .Commits}}{{with conventional .}}- {{upper .Type}}: {{.Description}}{{end}}` +
+		`{{with .Trailer "Refs"}} ({{.}}){{end}} by {{.Author}}
+{{indent 2 .Body}}
+{{end}}`)
+	assert.NoError(t, err)
+	r = &Release{
+		Module:      RootModule(),
+		PreviousTag: "v1.1.0",
+		Version:     mustVersion("1.2.0"),
+		TagName:     "v1.2.0",
+		Date:        time.Date(2020, 5, 17, 10, 0, 0, 0, time.UTC),
+		Commits: []*Commit{
+			{
+				Hash:     "abc1234",
+				Author:   "John Doe",
+				Subject:  "feat(cli): add --template-file",
+				Body:     "line 1\nline 2\n\nRefs: #12",
+				Trailers: []Trailer{{Key: "Refs", Value: "#12"}},
+			},
+		},
+	}
+	output, err = b.Annotation(r, "")
+	assert.NoError(t, err)
+	assert.Equal(t, ". 1.2 (2020-05-17) since v1.1.0\n- FEAT: add --template-file (#12) by John Doe\n"+
+		"  line 1\n  line 2\n\n  Refs: #12\n", output)
+
+	b.Template, err = ParseTemplate("{{.Unknown}}")
+	assert.NoError(t, err)
+	_, err = b.Annotation(r, "")
+	assert.Error(t, err)
+
+	_, err = ParseTemplate("{{.TagName")
+	assert.Error(t, err)
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	tmpl, err := ParseTemplate(`{{lower "A"}} {{trim " b "}} {{trimPrefix "v" "v1"}} {{trimSuffix ".0" "1.0"}} ` +
+		`{{replace "-" "_" "a-b"}} {{contains "b" "abc"}} {{hasPrefix "a" "abc"}} {{hasSuffix "c" "abc"}} ` +
+		`{{join "," (split " " "x y z")}}`)
+	assert.NoError(t, err)
+	var output strings.Builder
+	assert.NoError(t, tmpl.Execute(&output, nil))
+	assert.Equal(t, "a b 1 1 a_b true true true x,y,z", output.String())
+}
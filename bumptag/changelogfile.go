@@ -0,0 +1,136 @@
+package bumptag
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultChangelogFile is the change log file regenerated from all tags if no file is configured.
+const DefaultChangelogFile = "CHANGELOG.md"
+
+// changelogFileHeader is the header of a new change log file, see https://keepachangelog.com
+const changelogFileHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+`
+
+// ChangelogEntry returns the section of the release in the Keep a Changelog format, e.g. `## [1.2.0] - 2020-05-17`,
+// the tag name is used instead of the version for the nested modules.
+func ChangelogEntry(r *Release) string {
+	name := r.TagName
+	if r.Module == nil || r.Module.IsRoot() {
+		name = r.Version.String()
+	}
+	entry := fmt.Sprintf("## [%s] - %s\n", name, r.Date.Format("2006-01-02"))
+	if changeLog := strings.TrimSpace(r.ChangeLog); len(changeLog) > 0 {
+		entry += "\n" + changeLog + "\n"
+	}
+	return entry
+}
+
+// InsertChangelogEntry adds the entry before the latest release of the change log,
+// so the `## [Unreleased]` section stays on the top. The header is added to an empty change log.
+func InsertChangelogEntry(content, entry string) string {
+	if len(strings.TrimSpace(content)) == 0 {
+		return changelogFileHeader + "\n" + entry
+	}
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "## ") && !strings.HasPrefix(strings.ToLower(line), "## [unreleased]") {
+			return strings.Join(lines[:i], "") + entry + "\n" + strings.Join(lines[i:], "")
+		}
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + "\n" + entry
+}
+
+// FormatChangelog returns the change log file with the entries of the releases in the given order.
+func FormatChangelog(releases []*Release) string {
+	entries := make([]string, 0, len(releases))
+	for _, r := range releases {
+		entries = append(entries, ChangelogEntry(r))
+	}
+	return changelogFileHeader + "\n" + strings.Join(entries, "\n")
+}
+
+// repoPath returns the absolute path of the file and the path relative to the root,
+// the given path is relative to the root of the repository if it is not absolute.
+func (r *Repo) repoPath(filename string) (string, string, error) {
+	root, err := r.Backend.Root()
+	if err != nil {
+		return "", "", err
+	}
+	if root, err = realPath(root); err != nil {
+		return "", "", err
+	}
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(root, filename)
+	}
+	dir, err := realPath(filepath.Dir(filename))
+	if err != nil {
+		return "", "", err
+	}
+	filename = filepath.Join(dir, filepath.Base(filename))
+	rel, err := filepath.Rel(root, filename)
+	if err != nil {
+		return "", "", err
+	}
+	return filename, filepath.ToSlash(rel), nil
+}
+
+// writeFile writes the file keeping the mode of an existing file.
+func writeFile(filename, content string) error {
+	mode := fs.FileMode(0o644)
+	if stat, err := os.Stat(filename); err == nil {
+		mode = stat.Mode()
+	}
+	return os.WriteFile(filename, []byte(content), mode)
+}
+
+// WriteChangelogFile replaces the change log file with the entries of the releases, see FormatChangelog,
+// and returns the path of the file relative to the root. The path is relative to the root if it is not absolute.
+func (r *Repo) WriteChangelogFile(filename string, releases []*Release) (string, error) {
+	filename, rel, err := r.repoPath(filename)
+	if err != nil {
+		return "", err
+	}
+	return rel, writeFile(filename, FormatChangelog(releases))
+}
+
+// updateChangelogFile adds the entry of the release to the change log file and commits it.
+func (t *Tagger) updateChangelogFile(r *Release) error {
+	filename, rel, err := t.Repo.repoPath(t.ChangelogFile)
+	if err != nil {
+		return err
+	}
+	if err := t.guardCleanTree(rel); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filename)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	t.record(fmt.Sprintf("the changes of '%s'", rel), func() error {
+		if exists {
+			return writeFile(filename, string(data))
+		}
+		if err := os.Remove(filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	})
+	if err := writeFile(filename, InsertChangelogEntry(string(data), ChangelogEntry(r))); err != nil {
+		return err
+	}
+	return t.commit(fmt.Sprintf("Update %s for %s", filepath.Base(filename), r.TagName), []string{rel})
+}
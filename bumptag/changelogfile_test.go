@@ -0,0 +1,99 @@
+package bumptag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangelogEntry(t *testing.T) {
+	r := &Release{
+		Module:    RootModule(),
+		Version:   mustVersion("1.2.0"),
+		TagName:   "v1.2.0",
+		Date:      time.Date(2020, 5, 17, 10, 0, 0, 0, time.UTC),
+		ChangeLog: "* abc1234 test\n",
+	}
+	assert.Equal(t, "## [1.2.0] - 2020-05-17\n\n* abc1234 test\n", ChangelogEntry(r))
+
+	r.Module = ModuleAt("tools/foo")
+	r.TagName = "tools/foo/v1.2.0"
+	r.ChangeLog = ""
+	assert.Equal(t, "## [tools/foo/v1.2.0] - 2020-05-17\n", ChangelogEntry(r))
+}
+
+func TestInsertChangelogEntry(t *testing.T) {
+	entry := "## [1.2.0] - 2020-05-17\n\n* test\n"
+	assert.Equal(t, changelogFileHeader+"\n"+entry, InsertChangelogEntry("", entry))
+	assert.Equal(t, "# Changelog\n\n"+entry, InsertChangelogEntry("# Changelog", entry))
+	assert.Equal(t,
+		"# Changelog\n\n## [Unreleased]\n\n* wip\n\n"+entry+"\n## [1.1.0] - 2020-05-01\n\n* old\n",
+		InsertChangelogEntry("# Changelog\n\n## [Unreleased]\n\n* wip\n\n## [1.1.0] - 2020-05-01\n\n* old\n", entry),
+	)
+}
+
+func TestTaggerChangelogFile(t *testing.T) {
+	prepareCommit, repo := prepareGit(t)
+	prepareCommit()
+	tagger := &Tagger{Repo: repo, ChangelogFile: "docs/CHANGELOG.md"}
+	r := &Release{
+		Module:          RootModule(),
+		PreviousVersion: *mustVersion("1.1.0"),
+		Version:         mustVersion("1.2.0"),
+		TagName:         "v1.2.0",
+		Date:            time.Date(2020, 5, 17, 10, 0, 0, 0, time.UTC),
+		ChangeLog:       "* abc1234 test",
+		Annotation:      "test-annotation",
+	}
+	assert.Error(t, tagger.Tag(r))
+
+	assert.NoError(t, os.Mkdir("docs", 0o700))
+	assert.NoError(t, tagger.Tag(r))
+	data, err := os.ReadFile(filepath.Join("docs", "CHANGELOG.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, changelogFileHeader+"\n## [1.2.0] - 2020-05-17\n\n* abc1234 test\n", string(data))
+
+	output, err := git("", "log", "-1", "--pretty=%s%d")
+	assert.NoError(t, err)
+	assert.Equal(t, "Update CHANGELOG.md for v1.2.0 (HEAD -> master, tag: v1.2.0)", output)
+	output, err = git("", "status", "--porcelain")
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+
+	r.Version = mustVersion("1.3.0")
+	r.TagName = "v1.3.0"
+	abs, err := filepath.Abs(filepath.Join("docs", "CHANGELOG.md"))
+	assert.NoError(t, err)
+	tagger.ChangelogFile = abs
+	assert.NoError(t, tagger.Tag(r))
+	data, err = os.ReadFile(abs)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "## [1.3.0] - 2020-05-17\n\n* abc1234 test\n\n## [1.2.0] - 2020-05-17\n")
+}
+
+func TestWriteChangelogFile(t *testing.T) {
+	_, repo := prepareGit(t)
+	releases := []*Release{
+		{Module: RootModule(), Version: mustVersion("1.1.0"), ChangeLog: "* def5678 test"},
+		{Module: RootModule(), Version: mustVersion("1.0.0"), ChangeLog: "* abc1234 test"},
+	}
+	expected := changelogFileHeader + "\n## [1.1.0] - 0001-01-01\n\n* def5678 test\n\n## [1.0.0] - 0001-01-01\n\n* abc1234 test\n"
+	assert.Equal(t, expected, FormatChangelog(releases))
+
+	assert.NoError(t, os.WriteFile(DefaultChangelogFile, []byte("test"), 0o600))
+	rel, err := repo.WriteChangelogFile(DefaultChangelogFile, releases)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultChangelogFile, rel)
+	data, err := os.ReadFile(DefaultChangelogFile)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(data))
+	stat, err := os.Stat(DefaultChangelogFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), stat.Mode())
+
+	_, err = repo.WriteChangelogFile("docs/CHANGELOG.md", releases)
+	assert.Error(t, err)
+}
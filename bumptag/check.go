@@ -0,0 +1,168 @@
+package bumptag
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// The names of the pre-flight checks, see Tagger.Check.
+const (
+	// CheckClean checks the working tree has no uncommitted changes of the tracked files.
+	CheckClean = "clean"
+	// CheckBranch checks HEAD is on a release branch, see Tagger.Branches.
+	CheckBranch = "branch"
+	// CheckBehind checks the current branch is not behind its upstream after fetching it.
+	CheckBehind = "behind"
+	// CheckTag checks the tag does not exist locally or in the remote.
+	CheckTag = "tag"
+	// CheckVersion checks the new version is greater than the previous one.
+	CheckVersion = "version"
+)
+
+// CheckError is the failure of a pre-flight check, it matches ErrCheckFailed and the error of the check.
+type CheckError struct {
+	Check string
+	Err   error
+}
+
+func (e *CheckError) Error() string {
+	return fmt.Sprintf("the %s check failed: %s", e.Check, e.Err)
+}
+
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrCheckFailed) true.
+func (e *CheckError) Is(target error) bool {
+	return target == ErrCheckFailed
+}
+
+type check struct {
+	name string
+	run  func(t *Tagger, r *Release) error
+}
+
+// checks are the pre-flight checks in the order they run.
+var checks = []check{
+	{CheckClean, (*Tagger).checkClean},
+	{CheckBranch, (*Tagger).checkBranch},
+	{CheckBehind, (*Tagger).checkBehind},
+	{CheckTag, (*Tagger).checkTag},
+	{CheckVersion, (*Tagger).checkVersion},
+}
+
+// Checks returns the names of all pre-flight checks.
+func Checks() []string {
+	names := make([]string, 0, len(checks))
+	for _, c := range checks {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// ValidateCheck returns an error if the name is not a pre-flight check.
+func ValidateCheck(name string) error {
+	for _, c := range checks {
+		if c.name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown check '%s'", name)
+}
+
+// Check runs the pre-flight checks of the release except the skipped ones, see Tagger.SkipChecks.
+func (t *Tagger) Check(r *Release) error {
+	skip := make(map[string]bool, len(t.SkipChecks))
+	for _, name := range t.SkipChecks {
+		if err := ValidateCheck(name); err != nil {
+			return err
+		}
+		skip[name] = true
+	}
+	for _, c := range checks {
+		if skip[c.name] {
+			continue
+		}
+		if err := c.run(t, r); err != nil {
+			return &CheckError{Check: c.name, Err: err}
+		}
+	}
+	return nil
+}
+
+func (t *Tagger) checkClean(*Release) error {
+	return t.guardCleanTree("")
+}
+
+func (t *Tagger) checkBranch(*Release) error {
+	if len(t.Branches) == 0 {
+		return nil
+	}
+	branch, err := t.Repo.Backend.Branch()
+	if err != nil {
+		return err
+	}
+	if len(branch) == 0 {
+		return fmt.Errorf("HEAD is detached, the release branches are %s", strings.Join(t.Branches, ", "))
+	}
+	for _, pattern := range t.Branches {
+		ok, err := path.Match(pattern, branch)
+		if err != nil {
+			return fmt.Errorf("invalid branch pattern '%s': %w", pattern, err)
+		}
+		if ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("the branch '%s' is not a release branch: %s", branch, strings.Join(t.Branches, ", "))
+}
+
+func (t *Tagger) checkBehind(*Release) error {
+	behind, err := t.Repo.Backend.Behind()
+	if err != nil {
+		return err
+	}
+	if behind > 0 {
+		return fmt.Errorf("the branch is behind its upstream by %d commits, pull them first", behind)
+	}
+	return nil
+}
+
+func (t *Tagger) checkTag(r *Release) error {
+	names, err := t.Repo.Backend.Tags(r.TagName)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if name == r.TagName {
+			return fmt.Errorf("%w: '%s'", ErrTagExists, r.TagName)
+		}
+	}
+	remote, err := t.remote()
+	if errors.Is(err, ErrNoRemote) && !t.AutoPush {
+		// the tag cannot be pushed anyway
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	found, err := t.Repo.Backend.HasRemoteTag(remote, r.TagName)
+	if err != nil {
+		return err
+	}
+	if found {
+		return fmt.Errorf("%w in the remote '%s': '%s'", ErrTagExists, remote, r.TagName)
+	}
+	return nil
+}
+
+func (t *Tagger) checkVersion(r *Release) error {
+	if len(r.PreviousTag) == 0 || r.PreviousVersion.LessThan(r.Version) {
+		return nil
+	}
+	return fmt.Errorf("%w: '%s' is not greater than the previous version '%s'",
+		ErrInvalidVersion, r.Version, &r.PreviousVersion)
+}
@@ -0,0 +1,130 @@
+package bumptag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecks(t *testing.T) {
+	assert.Equal(t, []string{CheckClean, CheckBranch, CheckBehind, CheckTag, CheckVersion}, Checks())
+	assert.NoError(t, ValidateCheck(CheckBehind))
+	assert.EqualError(t, ValidateCheck("test-check"), "unknown check 'test-check'")
+
+	tagger := &Tagger{SkipChecks: []string{"test-check"}}
+	assert.EqualError(t, tagger.Check(&Release{}), "unknown check 'test-check'")
+}
+
+func TestCheckBranch(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	tagger := &Tagger{Repo: NewRepo(cli)}
+	assert.NoError(t, tagger.checkBranch(nil))
+
+	tagger.Branches = []string{"main", "release/*"}
+	for _, branch := range []string{"main", "release/1.x"} {
+		ctrl.EXPECT().
+			Git("", "rev-parse", "--abbrev-ref", "HEAD").
+			Return(branch, nil)
+		assert.NoError(t, tagger.checkBranch(nil), branch)
+	}
+
+	ctrl.EXPECT().
+		Git("", "rev-parse", "--abbrev-ref", "HEAD").
+		Return("feature/test", nil)
+	assert.EqualError(
+		t,
+		tagger.checkBranch(nil),
+		"the branch 'feature/test' is not a release branch: main, release/*",
+	)
+
+	ctrl.EXPECT().
+		Git("", "rev-parse", "--abbrev-ref", "HEAD").
+		Return("HEAD", nil)
+	assert.EqualError(t, tagger.checkBranch(nil), "HEAD is detached, the release branches are main, release/*")
+}
+
+func TestCheckBehind(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	tagger := &Tagger{Repo: NewRepo(cli)}
+
+	ctrl.EXPECT().
+		Git("", "rev-parse", "--abbrev-ref", "@{upstream}").
+		Return("", errors.New("test-error"))
+	assert.NoError(t, tagger.checkBehind(nil))
+
+	ctrl.EXPECT().
+		Git("", "rev-parse", "--abbrev-ref", "@{upstream}").
+		Return("origin/master", nil)
+	ctrl.EXPECT().
+		Git("", "fetch", "--quiet").
+		Return("", nil)
+	ctrl.EXPECT().
+		Git("", "rev-list", "--count", "HEAD..@{upstream}").
+		Return("2", nil)
+	assert.EqualError(t, tagger.checkBehind(nil), "the branch is behind its upstream by 2 commits, pull them first")
+}
+
+func TestCheckTag(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	tagger := &Tagger{Repo: NewRepo(cli), Remote: "origin"}
+	r := &Release{TagName: "v1.3.0"}
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list", "v1.3.0").
+		Return("v1.3.0", nil)
+	err := tagger.checkTag(r)
+	assert.EqualError(t, err, "the tag already exists: 'v1.3.0'")
+	assert.ErrorIs(t, err, ErrTagExists)
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list", "v1.3.0").
+		Return("", nil).
+		Times(3)
+	ctrl.EXPECT().
+		Git("", "config", "--get", "remote.origin.url").
+		Return("git@example.com:test/test.git", nil).
+		Times(2)
+	ctrl.EXPECT().
+		Git("", "ls-remote", "--tags", "--refs", "origin", "refs/tags/v1.3.0").
+		Return("abc1234\trefs/tags/v1.3.0", nil)
+	err = tagger.checkTag(r)
+	assert.EqualError(t, err, "the tag already exists in the remote 'origin': 'v1.3.0'")
+	assert.ErrorIs(t, err, ErrTagExists)
+
+	ctrl.EXPECT().
+		Git("", "ls-remote", "--tags", "--refs", "origin", "refs/tags/v1.3.0").
+		Return("", nil)
+	assert.NoError(t, tagger.checkTag(r))
+
+	ctrl.EXPECT().
+		Git("", "config", "--get", "remote.origin.url").
+		Return("", errors.New("test-error"))
+	assert.NoError(t, tagger.checkTag(r), "the remote tags are not checked without the remote")
+
+	tagger.AutoPush = true
+	ctrl.EXPECT().
+		Git("", "tag", "--list", "v1.3.0").
+		Return("", nil)
+	ctrl.EXPECT().
+		Git("", "config", "--get", "remote.origin.url").
+		Return("", errors.New("test-error"))
+	err = tagger.checkTag(r)
+	assert.EqualError(t, err, "no remote: the remote 'origin' is not configured")
+	assert.ErrorIs(t, err, ErrNoRemote)
+}
+
+func TestCheckVersion(t *testing.T) {
+	tagger := &Tagger{}
+	r := &Release{Version: mustVersion("1.0.0")}
+	assert.NoError(t, tagger.checkVersion(r))
+
+	r.PreviousTag = "v1.0.0"
+	r.PreviousVersion = *mustVersion("1.0.0")
+	err := tagger.checkVersion(r)
+	assert.EqualError(t, err, "invalid version: '1.0.0' is not greater than the previous version '1.0.0'")
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+
+	r.Version = mustVersion("1.0.1-rc.1")
+	assert.NoError(t, tagger.checkVersion(r))
+}
@@ -0,0 +1,361 @@
+package bumptag
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitFunc runs the git command with the input and returns its output.
+type GitFunc func(input string, arg ...string) (string, error)
+
+// runGit returns a function running the git binary in the directory, the current directory if empty.
+func runGit(dir string) GitFunc {
+	return func(input string, arg ...string) (string, error) {
+		cmd := exec.Command("git", arg...)
+		cmd.Dir = dir
+		if len(input) > 0 {
+			cmd.Stdin = strings.NewReader(input)
+		}
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			return "", &GitError{Args: cmd.Args, Stderr: strings.TrimSpace(stderr.String()), Err: err}
+		}
+		return strings.TrimSpace(stdout.String()), nil
+	}
+}
+
+// traceGit returns a function printing the git commands and the full error output of the failed ones to the writer.
+func traceGit(git GitFunc, w io.Writer) GitFunc {
+	return func(input string, arg ...string) (string, error) {
+		args := make([]string, 0, len(arg))
+		for _, a := range arg {
+			if len(a) == 0 || strings.ContainsAny(a, " \t\n'\"") {
+				a = strconv.Quote(a)
+			}
+			args = append(args, a)
+		}
+		fmt.Fprintf(w, "+ git %s\n", strings.Join(args, " "))
+		output, err := git(input, arg...)
+		var gitErr *GitError
+		switch {
+		case errors.As(err, &gitErr):
+			fmt.Fprintln(w, gitErr.Err)
+			if len(gitErr.Stderr) > 0 {
+				fmt.Fprintln(w, gitErr.Stderr)
+			}
+		case err != nil:
+			fmt.Fprintln(w, err)
+		}
+		return output, err
+	}
+}
+
+func splitLines(output string) []string {
+	if len(output) == 0 {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
+// pathspec limits git commands to the path except the excluded paths.
+func pathspec(path string, excludes []string) []string {
+	if len(path) == 0 && len(excludes) == 0 {
+		return nil
+	}
+	res := []string{"--", ":(top)" + path}
+	for _, exclude := range excludes {
+		res = append(res, ":(top,exclude)"+exclude)
+	}
+	return res
+}
+
+func parseRemote(remote string) (string, error) {
+	for _, part := range strings.Split(remote, " ") {
+		if strings.HasPrefix(part, "[") {
+			part = strings.Trim(part, "[]")
+			names := strings.SplitN(part, "/", 2)
+			if len(names) != 2 {
+				return "", fmt.Errorf("%w: cannot determine a remote name: %s", ErrNoRemote, part)
+			}
+			return names[0], nil
+		}
+	}
+	return "", fmt.Errorf("%w for the active branch '%s'", ErrNoRemote, remote)
+}
+
+// CLIBackend runs the git binary.
+type CLIBackend struct {
+	git GitFunc
+}
+
+// NewCLIBackend returns the backend running the git binary in the directory, the current directory if empty.
+func NewCLIBackend(dir string) *CLIBackend {
+	return &CLIBackend{git: runGit(dir)}
+}
+
+// SetTrace prints the git commands and the full error output of the failed ones to the writer.
+func (b *CLIBackend) SetTrace(w io.Writer) {
+	b.git = traceGit(b.git, w)
+}
+
+func (b *CLIBackend) noOutputGit(input string, arg ...string) error {
+	_, err := b.git(input, arg...)
+	return err
+}
+
+func (b *CLIBackend) disableGPG() (string, error) {
+	output, _ := b.git("", "config", "--local", "--get", "log.showSignature")
+	if err := b.noOutputGit("", "config", "--local", "log.showSignature", "false"); err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+func (b *CLIBackend) restoreGPG(oldValue string) error {
+	if len(oldValue) > 0 {
+		return b.noOutputGit("", "config", "--local", "log.showSignature", oldValue)
+	}
+	return b.noOutputGit("", "config", "--local", "--unset", "log.showSignature")
+}
+
+// Setup disables the signatures in the log output, because they break the parsing of the commits.
+func (b *CLIBackend) Setup() (func(), error) {
+	oldValue, err := b.disableGPG()
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		_ = b.restoreGPG(oldValue)
+	}, nil
+}
+
+func (b *CLIBackend) Root() (string, error) {
+	return b.git("", "rev-parse", "--show-toplevel")
+}
+
+func (b *CLIBackend) Config(name string) (string, error) {
+	return b.git("", "config", "--get", name)
+}
+
+func (b *CLIBackend) Tags(pattern string) ([]string, error) {
+	args := []string{"tag", "--list"}
+	if len(pattern) > 0 {
+		args = append(args, pattern)
+	}
+	output, err := b.git("", args...)
+	return splitLines(output), err
+}
+
+func (b *CLIBackend) MergedTags() ([]string, error) {
+	output, err := b.git("", "tag", "--merged", "HEAD")
+	return splitLines(output), err
+}
+
+func (b *CLIBackend) DescribeTag(match, exclude string) (string, error) {
+	args := []string{"describe", "--tags", "--abbrev=0"}
+	if len(match) > 0 {
+		args = append(args, "--match", match)
+	}
+	if len(exclude) > 0 {
+		args = append(args, "--exclude", exclude)
+	}
+	return b.git("", args...)
+}
+
+func (b *CLIBackend) PointsAt(tagName string) ([]string, error) {
+	output, err := b.git("", "tag", "--points-at", tagName+"^{commit}")
+	return splitLines(output), err
+}
+
+func (b *CLIBackend) Log(since, until, path string, excludes []string) ([]*Commit, error) {
+	args := []string{"log", "--pretty=%h%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%b%x1e", "--no-merges"}
+	switch {
+	case len(since) > 0 && len(until) > 0:
+		args = append(args, since+".."+until)
+	case len(since) > 0:
+		args = append(args, since+"..HEAD")
+	case len(until) > 0:
+		args = append(args, until)
+	}
+	args = append(args, pathspec(path, excludes)...)
+	output, err := b.git("", args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommits(output), nil
+}
+
+func (b *CLIBackend) ReadTag(tagName string) (*Tag, error) {
+	output, err := b.git("", "for-each-ref", "--format=%(objecttype)%1f%(creatordate:iso-strict)%1f%(contents:body)",
+		"refs/tags/"+tagName)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.SplitN(output, fieldSeparator, 3)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("tag '%s' not found", tagName)
+	}
+	date, err := time.Parse(time.RFC3339, fields[1])
+	if err != nil {
+		return nil, err
+	}
+	tag := &Tag{Name: tagName, Date: normalizeDate(date)}
+	if fields[0] == "tag" {
+		tag.Body = strings.TrimSpace(fields[2])
+	}
+	return tag, nil
+}
+
+func (b *CLIBackend) ShortHash(rev string) (string, error) {
+	return b.git("", "rev-parse", "--short", rev)
+}
+
+func (b *CLIBackend) ReadFile(rev, path string) (string, error) {
+	output, err := b.git("", "show", rev+":"+path)
+	if err == nil {
+		return output, nil
+	}
+	// git show fails the same way for a bad revision and a missing file, ls-tree tells them apart
+	found, lsErr := b.git("", "ls-tree", "--full-tree", "--name-only", rev, "--", path)
+	if lsErr == nil && len(found) == 0 {
+		return "", fmt.Errorf("cannot read the file '%s' at '%s': %w", path, rev, fs.ErrNotExist)
+	}
+	return "", err
+}
+
+func (b *CLIBackend) FindFiles(name string) ([]string, error) {
+	output, err := b.git("", "ls-files", "--full-name", "--", ":(top,glob)**/"+name)
+	return splitLines(output), err
+}
+
+func (b *CLIBackend) ChangedFiles() ([]string, error) {
+	output, err := b.git("", "diff", "--name-only", "HEAD")
+	return splitLines(output), err
+}
+
+// Checkout creates a temporary worktree of the revision.
+func (b *CLIBackend) Checkout(rev string) (string, func(), error) {
+	tmp, err := os.MkdirTemp("", "bumptag")
+	if err != nil {
+		return "", nil, err
+	}
+	dir := filepath.Join(tmp, "worktree")
+	if err := b.noOutputGit("", "worktree", "add", "--detach", dir, rev); err != nil {
+		_ = os.RemoveAll(tmp)
+		return "", nil, err
+	}
+	return dir, func() {
+		_ = b.noOutputGit("", "worktree", "remove", "--force", dir)
+		_ = os.RemoveAll(tmp)
+	}, nil
+}
+
+func (b *CLIBackend) Commit(message string, files []string) error {
+	args := []string{"--"}
+	for _, name := range files {
+		args = append(args, ":(top)"+name)
+	}
+	if err := b.noOutputGit("", append([]string{"add"}, args...)...); err != nil {
+		return err
+	}
+	return b.noOutputGit("", append([]string{"commit", "-m", message}, args...)...)
+}
+
+func (b *CLIBackend) CreateTag(tagName, annotation string, sign bool, signKey string) error {
+	args := []string{"tag", "-F-"}
+	switch {
+	case sign && len(signKey) > 0:
+		args = append(args, "--local-user", signKey)
+	case sign:
+		args = append(args, "--sign")
+	default:
+		// tag.gpgSign would sign the tag otherwise
+		args = append(args, "--no-sign")
+	}
+	args = append(args, tagName)
+	return b.noOutputGit(annotation, args...)
+}
+
+// VerifyTag reports the last line of the output of the failed verification, e.g. `No principal matched.`,
+// the first lines describe the signature.
+func (b *CLIBackend) VerifyTag(tagName string) error {
+	err := b.noOutputGit("", "tag", "--verify", tagName)
+	var gitErr *GitError
+	if errors.As(err, &gitErr) {
+		lines := strings.Split(strings.TrimSpace(gitErr.Stderr), "\n")
+		return &GitError{Args: gitErr.Args, Stderr: lines[len(lines)-1], Err: gitErr.Err}
+	}
+	return err
+}
+
+func (b *CLIBackend) DeleteTag(tagName string) error {
+	return b.noOutputGit("", "tag", "--delete", tagName)
+}
+
+func (b *CLIBackend) Reset(rev string) error {
+	return b.noOutputGit("", "reset", "--quiet", "--keep", rev)
+}
+
+func (b *CLIBackend) ShowTag(tagName string) (string, error) {
+	return b.git("", "show", tagName)
+}
+
+func (b *CLIBackend) Remote() (string, error) {
+	output, err := b.git("", "branch", "--list", "-vv")
+	if err != nil {
+		return "", err
+	}
+	for _, remote := range strings.Split(output, "\n") {
+		remote = strings.TrimSpace(remote)
+		if strings.HasPrefix(remote, "*") {
+			return parseRemote(remote)
+		}
+	}
+	return defaultRemote, nil
+}
+
+func (b *CLIBackend) Branch() (string, error) {
+	output, err := b.git("", "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil || output == "HEAD" {
+		return "", err
+	}
+	return output, nil
+}
+
+func (b *CLIBackend) Behind() (int, error) {
+	if _, err := b.git("", "rev-parse", "--abbrev-ref", "@{upstream}"); err != nil {
+		// the branch has no upstream or HEAD is detached
+		return 0, nil
+	}
+	if err := b.noOutputGit("", "fetch", "--quiet"); err != nil {
+		return 0, err
+	}
+	output, err := b.git("", "rev-list", "--count", "HEAD..@{upstream}")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(output)
+}
+
+func (b *CLIBackend) HasRemoteTag(remote, tagName string) (bool, error) {
+	output, err := b.git("", "ls-remote", "--tags", "--refs", remote, "refs/tags/"+tagName)
+	return len(output) > 0, err
+}
+
+func (b *CLIBackend) PushTag(remote, tagName string) error {
+	return b.noOutputGit("", "push", remote, tagName)
+}
@@ -0,0 +1,390 @@
+package bumptag
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunGit(t *testing.T) {
+	output, err := runGit("")("", "status")
+	assert.NoError(t, err)
+	t.Log(output)
+
+	output, err = runGit("")("fake", "fail-cmd")
+	assert.ErrorIs(t, err, ErrGitFailed)
+	var gitErr *GitError
+	assert.ErrorAs(t, err, &gitErr)
+	assert.Equal(t, []string{"git", "fail-cmd"}, gitErr.Args)
+	assert.Contains(t, gitErr.Stderr, "'fail-cmd' is not a git command")
+	t.Log(output)
+
+	_, err = runGit(t.TempDir())("", "status")
+	assert.Error(t, err)
+}
+
+func TestTraceGit(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	var trace bytes.Buffer
+	cli.SetTrace(&trace)
+
+	ctrl.EXPECT().
+		Git("", "commit", "-m", "test message").
+		Return("", nil)
+	assert.NoError(t, cli.noOutputGit("", "commit", "-m", "test message"))
+	ctrl.EXPECT().
+		Git("", "push", "origin", "v1.0.0").
+		Return("", &GitError{
+			Args:   []string{"git", "push", "origin", "v1.0.0"},
+			Stderr: "fatal: test-error\nfatal: Could not read from remote repository.",
+			Err:    errors.New("exit status 128"),
+		})
+	err := cli.PushTag("origin", "v1.0.0")
+	assert.EqualError(t, err, "command 'git push origin v1.0.0' failed: exit status 128: fatal: test-error")
+	assert.Equal(
+		t,
+		"+ git commit -m \"test message\"\n"+
+			"+ git push origin v1.0.0\nexit status 128\nfatal: test-error\nfatal: Could not read from remote repository.\n",
+		trace.String(),
+	)
+}
+
+func TestChangedFiles(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	ctrl.EXPECT().
+		Git("", "diff", "--name-only", "HEAD").
+		Return("CHANGELOG.md\ntools/foo/go.mod", nil)
+	files, err := cli.ChangedFiles()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CHANGELOG.md", "tools/foo/go.mod"}, files)
+}
+
+func TestDisabelGPG(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	ctrl.EXPECT().
+		Git("", "config", "--local", "--get", "log.showSignature").
+		Return("true", nil)
+	ctrl.EXPECT().
+		Git("", "config", "--local", "log.showSignature", "false").
+		Return("", nil)
+	output, err := cli.disableGPG()
+	assert.NoError(t, err)
+	assert.Equal(t, "true", output)
+
+	ctrl.EXPECT().
+		Git("", "config", "--local", "--get", "log.showSignature").
+		Return("", errors.New("error 1"))
+	ctrl.EXPECT().
+		Git("", "config", "--local", "log.showSignature", "false").
+		Return("", errors.New("error 2"))
+	output, err = cli.disableGPG()
+	assert.Error(t, err)
+	assert.Equal(t, "", output)
+	assert.Equal(t, "error 2", err.Error())
+}
+
+func TestRestoreGPG(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	ctrl.EXPECT().
+		Git("", "config", "--local", "log.showSignature", "test-value").
+		Return("true", nil)
+	err := cli.restoreGPG("test-value")
+	assert.NoError(t, err)
+
+	ctrl.EXPECT().
+		Git("", "config", "--local", "--unset", "log.showSignature").
+		Return("", nil)
+	err = cli.restoreGPG("")
+	assert.NoError(t, err)
+}
+
+func TestSetup(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	// Check failing at disabling the log.showSignature
+	ctrl.EXPECT().
+		Git("", "config", "--local", "--get", "log.showSignature").
+		Return("", nil)
+	ctrl.EXPECT().
+		Git("", "config", "--local", "log.showSignature", "false").
+		Return("", errors.New("test-error"))
+	_, err := cli.Setup()
+	assert.Error(t, err)
+
+	// Check right behavior
+	ctrl.EXPECT().
+		Git("", "config", "--local", "--get", "log.showSignature").
+		Return("test-value", nil)
+	ctrl.EXPECT().
+		Git("", "config", "--local", "log.showSignature", "false").
+		Return("", nil)
+	restore, err := cli.Setup()
+	assert.NoError(t, err)
+
+	ctrl.EXPECT().
+		Git("", "config", "--local", "log.showSignature", "test-value").
+		Return("true", nil)
+	restore()
+}
+
+func TestCreateTag(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	ctrl.EXPECT().
+		Git("test-annotation", "tag", "-F-", "--no-sign", "test-tag").
+		Return("", nil)
+	err := cli.CreateTag("test-tag", "test-annotation", false, "test-key")
+	assert.NoError(t, err)
+
+	ctrl.EXPECT().
+		Git("test-annotation", "tag", "-F-", "--sign", "test-tag").
+		Return("", nil)
+	err = cli.CreateTag("test-tag", "test-annotation", true, "")
+	assert.NoError(t, err)
+
+	ctrl.EXPECT().
+		Git("test-annotation", "tag", "-F-", "--local-user", "test-key", "test-tag").
+		Return("", nil)
+	err = cli.CreateTag("test-tag", "test-annotation", true, "test-key")
+	assert.NoError(t, err)
+
+	ctrl.EXPECT().
+		Git("test-annotation", "tag", "-F-", "--sign", "test-tag").
+		Return("", errors.New("test-error"))
+	err = cli.CreateTag("test-tag", "test-annotation", true, "")
+	assert.Error(t, err)
+	assert.Equal(t, "test-error", err.Error())
+}
+
+func TestVerifyTag(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	ctrl.EXPECT().
+		Git("", "tag", "--verify", "test-tag").
+		Return("", nil)
+	assert.NoError(t, cli.VerifyTag("test-tag"))
+
+	ctrl.EXPECT().
+		Git("", "tag", "--verify", "test-tag").
+		Return("", errors.New("test-error"))
+	assert.EqualError(t, cli.VerifyTag("test-tag"), "test-error")
+
+	ctrl.EXPECT().
+		Git("", "tag", "--verify", "test-tag").
+		Return("", &GitError{
+			Args:   []string{"git", "tag", "--verify", "test-tag"},
+			Stderr: "Good \"git\" signature with ED25519 key SHA256:test\nNo principal matched.\n",
+			Err:    errors.New("exit status 1"),
+		})
+	err := cli.VerifyTag("test-tag")
+	assert.EqualError(t, err, "command 'git tag --verify test-tag' failed: exit status 1: No principal matched.")
+	assert.ErrorIs(t, err, ErrGitFailed)
+}
+
+func TestShowTag(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	ctrl.EXPECT().
+		Git("", "show", "test-tag").
+		Return("test-output", nil)
+	output, err := cli.ShowTag("test-tag")
+	assert.NoError(t, err)
+	assert.Equal(t, "test-output", output)
+
+	ctrl.EXPECT().
+		Git("", "show", "test-tag").
+		Return("", errors.New("test-error"))
+	_, err = cli.ShowTag("test-tag")
+	assert.Error(t, err)
+	assert.Equal(t, "test-error", err.Error())
+}
+
+func TestRemote(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	ctrl.EXPECT().
+		Git("", "branch", "--list", "-vv").
+		Return("", errors.New("test-error"))
+	output, err := cli.Remote()
+	assert.Error(t, err)
+	assert.Empty(t, output)
+
+	ctrl.EXPECT().
+		Git("", "branch", "--list", "-vv").
+		Return("", nil)
+	output, err = cli.Remote()
+	assert.NoError(t, err)
+	assert.Equal(t, defaultRemote, output)
+
+	ctrl.EXPECT().
+		Git("", "branch", "--list", "-vv").
+		Return(`
+  master       cc51028 [origin/master] Merge pull request #6 from SVilgelm/tests
+* new_features b2fedca Add silent mode`, nil)
+	output, err = cli.Remote()
+	assert.Error(t, err)
+	assert.Empty(t, output)
+
+	ctrl.EXPECT().
+		Git("", "branch", "--list", "-vv").
+		Return(`
+  master       cc51028 [origin/master] Merge pull request #6 from SVilgelm/tests
+* new_features b2fedca [Add silent mode]`, nil)
+	output, err = cli.Remote()
+	assert.Error(t, err)
+	assert.Empty(t, output)
+
+	ctrl.EXPECT().
+		Git("", "branch", "--list", "-vv").
+		Return(`
+* master       cc51028 [test-origin/master] Merge pull request #6 from SVilgelm/tests
+  new_features b2fedca [Add silent mode]`, nil)
+	output, err = cli.Remote()
+	assert.NoError(t, err)
+	assert.Equal(t, "test-origin", output)
+}
+
+func TestPushTag(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	ctrl.EXPECT().
+		Git("", "push", "test-remote", "test-tag").
+		Return("", errors.New("test-error"))
+	err := cli.PushTag("test-remote", "test-tag")
+	assert.EqualError(t, err, "test-error")
+}
+
+func TestPathspec(t *testing.T) {
+	assert.Empty(t, pathspec("", nil))
+	assert.Equal(t, []string{"--", ":(top)tools/foo"}, pathspec("tools/foo", nil))
+	assert.Equal(t, []string{"--", ":(top)", ":(top,exclude)tools/foo"}, pathspec("", []string{"tools/foo"}))
+}
+
+func TestTags(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list").
+		Return("", nil)
+	names, err := cli.Tags("")
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list", "tools/foo/v*").
+		Return("tools/foo/v1.0.0\ntools/foo/v1.1.0", nil)
+	names, err = cli.Tags("tools/foo/v*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tools/foo/v1.0.0", "tools/foo/v1.1.0"}, names)
+}
+
+func TestLog(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	ctrl.EXPECT().
+		Git("", "log", "--pretty=%h%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%b%x1e", "--no-merges", "test-tag..HEAD", "--", ":(top)tools/foo").
+		Return("abc1234\x1fJohn Doe\x1fjohn@example.com\x1f2020-01-02T03:04:05+01:00\x1ftest\x1f\x1e",
+			nil)
+	commits, err := cli.Log("test-tag", "", "tools/foo", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Commit{{
+		Hash:        "abc1234",
+		Author:      "John Doe",
+		AuthorEmail: "john@example.com",
+		Date:        time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("", 3600)),
+		Subject:     "test",
+	}}, commits)
+
+	ctrl.EXPECT().
+		Git("", "log", "--pretty=%h%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%b%x1e", "--no-merges").
+		Return("", errors.New("test-error"))
+	_, err = cli.Log("", "", "", nil)
+	assert.EqualError(t, err, "test-error")
+
+	ctrl.EXPECT().
+		Git("", "log", "--pretty=%h%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%b%x1e", "--no-merges", "v1.0.0..v1.1.0").
+		Return("", nil)
+	commits, err = cli.Log("v1.0.0", "v1.1.0", "", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, commits)
+
+	ctrl.EXPECT().
+		Git("", "log", "--pretty=%h%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%b%x1e", "--no-merges", "v1.0.0").
+		Return("", nil)
+	commits, err = cli.Log("", "v1.0.0", "", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, commits)
+}
+
+func TestReadTag(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	format := "--format=%(objecttype)%1f%(creatordate:iso-strict)%1f%(contents:body)"
+
+	ctrl.EXPECT().
+		Git("", "for-each-ref", format, "refs/tags/v1.0.0").
+		Return("tag\x1f2020-05-17T10:00:00+02:00\x1f* abc1234 test\n", nil)
+	tag, err := cli.ReadTag("v1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, &Tag{
+		Name: "v1.0.0",
+		Date: time.Date(2020, 5, 17, 10, 0, 0, 0, time.FixedZone("", 7200)),
+		Body: "* abc1234 test",
+	}, tag)
+
+	ctrl.EXPECT().
+		Git("", "for-each-ref", format, "refs/tags/v1.1.0").
+		Return("commit\x1f2020-05-17T10:00:00Z\x1fthe body of the commit", nil)
+	tag, err = cli.ReadTag("v1.1.0")
+	assert.NoError(t, err)
+	assert.Empty(t, tag.Body)
+
+	ctrl.EXPECT().
+		Git("", "for-each-ref", format, "refs/tags/v1.2.0").
+		Return("", nil)
+	_, err = cli.ReadTag("v1.2.0")
+	assert.EqualError(t, err, "tag 'v1.2.0' not found")
+
+	ctrl.EXPECT().
+		Git("", "for-each-ref", format, "refs/tags/v1.3.0").
+		Return("tag\x1ftest-date\x1f", nil)
+	_, err = cli.ReadTag("v1.3.0")
+	assert.Error(t, err)
+}
+
+func TestCommit(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	addCall := ctrl.EXPECT().
+		Git("", "add", "--", ":(top)go.mod", ":(top)main.go").
+		Return("", nil)
+	ctrl.EXPECT().
+		Git("", "commit", "-m", "test-message", "--", ":(top)go.mod", ":(top)main.go").
+		Return("", nil).After(addCall)
+	assert.NoError(t, cli.Commit("test-message", []string{"go.mod", "main.go"}))
+
+	ctrl.EXPECT().
+		Git("", "add", "--", ":(top)go.mod").
+		Return("", errors.New("test-error"))
+	assert.EqualError(t, cli.Commit("test-message", []string{"go.mod"}), "test-error")
+}
+
+func TestDeleteTag(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	ctrl.EXPECT().
+		Git("", "tag", "--delete", "v1.3.0").
+		Return("", nil)
+	assert.NoError(t, cli.DeleteTag("v1.3.0"))
+}
+
+func TestReset(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	ctrl.EXPECT().
+		Git("", "reset", "--quiet", "--keep", "cc51028").
+		Return("", nil)
+	assert.NoError(t, cli.Reset("cc51028"))
+}
@@ -0,0 +1,106 @@
+package bumptag
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	fieldSeparator  = "\x1f"
+	commitSeparator = "\x1e"
+)
+
+// Trailer is a `Key: value` line at the end of the commit message, e.g. `Signed-off-by: John Doe <john@example.com>`.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// Commit is a commit of the change log.
+type Commit struct {
+	// Hash is the abbreviated hash
+	Hash        string
+	Author      string
+	AuthorEmail string
+	// Date is the author date
+	Date     time.Time
+	Subject  string
+	Body     string
+	Trailers []Trailer
+}
+
+func (c *Commit) String() string {
+	return c.Hash + " " + c.Subject
+}
+
+// Trailer returns the value of the first trailer with the key, the keys are case-insensitive.
+func (c *Commit) Trailer(key string) string {
+	for _, t := range c.Trailers {
+		if strings.EqualFold(t.Key, key) {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+var trailerRe = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z0-9][A-Za-z0-9-]*):\s*(.*)$`)
+
+// parseTrailers returns the trailers of the last paragraph of the body,
+// the paragraph is ignored if any of its lines is not a trailer.
+func parseTrailers(body string) []Trailer {
+	paragraphs := strings.Split(strings.TrimSpace(body), "\n\n")
+	last := strings.TrimSpace(paragraphs[len(paragraphs)-1])
+	if len(last) == 0 {
+		return nil
+	}
+	var res []Trailer
+	for _, line := range strings.Split(last, "\n") {
+		parts := trailerRe.FindStringSubmatch(strings.TrimSpace(line))
+		if parts == nil {
+			return nil
+		}
+		res = append(res, Trailer{Key: parts[1], Value: parts[2]})
+	}
+	return res
+}
+
+// normalizeDate returns the same date in the same zone regardless of the backend.
+func normalizeDate(date time.Time) time.Time {
+	if date.IsZero() {
+		return date
+	}
+	_, offset := date.Zone()
+	return time.Unix(date.Unix(), 0).In(time.FixedZone("", offset))
+}
+
+func newCommit(hash, author, email string, date time.Time, subject, body string) *Commit {
+	return &Commit{
+		Hash:        hash,
+		Author:      author,
+		AuthorEmail: email,
+		Date:        normalizeDate(date),
+		Subject:     subject,
+		Body:        body,
+		Trailers:    parseTrailers(body),
+	}
+}
+
+// parseCommits parses the output of `git log` with the fields hash, author name, author email,
+// author date in the strict ISO 8601 format, subject and body.
+func parseCommits(output string) []*Commit {
+	var res []*Commit
+	for _, record := range strings.Split(output, commitSeparator) {
+		record = strings.TrimSpace(record)
+		if len(record) == 0 {
+			continue
+		}
+		fields := strings.SplitN(record, fieldSeparator, 6)
+		for len(fields) < 6 {
+			fields = append(fields, "")
+		}
+		date, _ := time.Parse(time.RFC3339, fields[3])
+		res = append(res, newCommit(fields[0], fields[1], fields[2], date, fields[4], strings.TrimSpace(fields[5])))
+	}
+	return res
+}
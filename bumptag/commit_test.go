@@ -0,0 +1,46 @@
+package bumptag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrailers(t *testing.T) {
+	assert.Nil(t, parseTrailers(""))
+	assert.Nil(t, parseTrailers("Some text"))
+	assert.Nil(t, parseTrailers("Some text\n\nRefs: #12\nnot a trailer"))
+	assert.Equal(t, []Trailer{
+		{Key: "Refs", Value: "#12"},
+		{Key: "Signed-off-by", Value: "John Doe <john@example.com>"},
+		{Key: "BREAKING CHANGE", Value: "the output is changed"},
+	}, parseTrailers(
+		"Some text\n\nRefs: #12\nSigned-off-by: John Doe <john@example.com>\nBREAKING CHANGE: the output is changed\n",
+	))
+}
+
+func TestCommitTrailer(t *testing.T) {
+	c := &Commit{Trailers: []Trailer{{Key: "Refs", Value: "#12"}, {Key: "refs", Value: "#13"}}}
+	assert.Equal(t, "#12", c.Trailer("REFS"))
+	assert.Empty(t, c.Trailer("Signed-off-by"))
+}
+
+func TestParseCommits(t *testing.T) {
+	assert.Nil(t, parseCommits(""))
+	assert.Equal(t, []*Commit{
+		{
+			Hash:        "abc1234",
+			Author:      "John Doe",
+			AuthorEmail: "john@example.com",
+			Date:        time.Date(2020, 5, 17, 10, 0, 0, 0, time.FixedZone("", -7200)),
+			Subject:     "feat: test",
+			Body:        "body\n\nRefs: #12",
+			Trailers:    []Trailer{{Key: "Refs", Value: "#12"}},
+		},
+		{Hash: "def5678", Subject: "fix: test"},
+	}, parseCommits(
+		"abc1234\x1fJohn Doe\x1fjohn@example.com\x1f2020-05-17T10:00:00-02:00\x1ffeat: test\x1fbody\n\nRefs: #12\n\x1e\n"+
+			"def5678\x1f\x1f\x1f\x1ffix: test\x1f\x1e\n",
+	))
+}
@@ -0,0 +1,471 @@
+package bumptag
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFiles are the names of the configuration files in the root of the repository, the first found is used.
+var ConfigFiles = []string{".bumptag.yaml", ".bumptag.yml", ".bumptag.toml"}
+
+// Config is the configuration of the repository.
+//
+// The settings are merged from the defaults, the configuration file (see ConfigFiles),
+// the `bumptag.*` git config options and the `BUMPTAG_*` environment variables, the later sources win:
+//
+//	setting         file            git config             environment
+//	prefix          prefix          bumptag.prefix         BUMPTAG_PREFIX
+//	level           level           bumptag.level          BUMPTAG_LEVEL
+//	strategy        strategy        bumptag.strategy       BUMPTAG_STRATEGY
+//	remote          remote          bumptag.remote         BUMPTAG_REMOTE
+//	sign            sign            bumptag.sign           BUMPTAG_SIGN
+//	sign-key        sign-key        bumptag.signKey        BUMPTAG_SIGN_KEY
+//	auto-push       auto-push       bumptag.autoPush       BUMPTAG_AUTO_PUSH
+//	auto            auto            bumptag.auto           BUMPTAG_AUTO
+//	editor          editor          bumptag.editor         BUMPTAG_EDITOR
+//	template-file   template-file   bumptag.templateFile   BUMPTAG_TEMPLATE_FILE
+//	template        template        bumptag.template       BUMPTAG_TEMPLATE
+//	group           group           bumptag.group          BUMPTAG_GROUP
+//	changelog-file  changelog-file  bumptag.changelogFile  BUMPTAG_CHANGELOG_FILE
+//	release         release         bumptag.release        BUMPTAG_RELEASE
+//	release-url     release-url     bumptag.releaseUrl     BUMPTAG_RELEASE_URL
+//	release-project release-project bumptag.releaseProject BUMPTAG_RELEASE_PROJECT
+//	release-assets  release-assets  bumptag.releaseAssets  BUMPTAG_RELEASE_ASSETS
+//	branches        branches        bumptag.branches       BUMPTAG_BRANCHES
+//	skip-checks     skip-checks     bumptag.skipChecks     BUMPTAG_SKIP_CHECKS
+//	bump-files      bump-files      bumptag.bumpFiles      BUMPTAG_BUMP_FILES
+//	bump-message    bump-message    bumptag.bumpMessage    BUMPTAG_BUMP_MESSAGE
+//	pre-bump        pre-bump        bumptag.preBump        BUMPTAG_PRE_BUMP
+//	pre-tag         pre-tag         bumptag.preTag         BUMPTAG_PRE_TAG
+//	post-tag        post-tag        bumptag.postTag        BUMPTAG_POST_TAG
+//	post-push       post-push       bumptag.postPush       BUMPTAG_POST_PUSH
+//
+// The patterns of the change log sections are set by the `section-breaking-changes`, `section-features`,
+// `section-bug-fixes` and `section-performance` settings, the `bumptag.sectionBreakingChanges`, etc. git config options
+// and the `BUMPTAG_SECTION_BREAKING_CHANGES`, etc. environment variables; an empty pattern removes the section.
+// The `release-assets`, `branches`, `skip-checks` and `bump-files` settings are comma separated lists.
+//
+// The `replace` setting of the configuration file is the list of the replacements, see Replacement,
+// with the `file`, `pattern` and `replacement` fields, e.g.
+//
+//	replace:
+//	  - file: Dockerfile
+//	    pattern: '(ARG VERSION=)\S+'
+//	    replacement: '${1}{{.Version}}'
+type Config struct {
+	// Prefix is the prefix of the versions in the tag names, `v` by default.
+	Prefix string
+	// Level is the default bump level, MINOR by default.
+	Level BumpLevel
+	// Strategy is the strategy to find the latest tag, describe by default.
+	Strategy string
+	// Remote is the remote to push the tags to, the remote of the current branch if empty.
+	Remote string
+	// Sign signs the tags, the value of `tag.gpgSign` or `commit.gpgsign` by default.
+	Sign bool
+	// SignKey is the key to sign the tags with, the value of `user.signingkey` if empty.
+	SignKey string
+	// AutoPush pushes the created tags.
+	AutoPush bool
+	// Auto detects the bump level from the Conventional Commits messages.
+	Auto bool
+	// Editor is the command to edit the annotations, the EDITOR environment variable is used if empty.
+	Editor string
+	// TemplateFile is the file of the annotation template, the path is relative to the root of the repository.
+	TemplateFile string
+	// Template is the annotation template, see ParseTemplate. It is read from TemplateFile if not set inline.
+	Template string
+	// Group groups the change log by the sections.
+	Group bool
+	// Sections are the sections of the grouped change log, DefaultSections by default.
+	Sections []*Section
+	// ChangelogFile is the change log file to update before tagging, the path is relative to the root of the repository.
+	ChangelogFile string
+	// Release is the forge to create the releases of the pushed tags on: github, gitea or gitlab.
+	Release string
+	// ReleaseURL is the base URL of the API of the forge, it is detected from the URL of the remote if empty.
+	ReleaseURL string
+	// ReleaseProject is the repository on the forge, it is detected from the URL of the remote if empty.
+	ReleaseProject string
+	// ReleaseAssets are the glob patterns of the files to upload to the releases, comma separated in the settings.
+	ReleaseAssets []string
+	// Branches are the glob patterns of the release branches, any branch is allowed if empty.
+	Branches []string
+	// SkipChecks are the names of the pre-flight checks not to run, see Tagger.Check.
+	SkipChecks []string
+	// BumpFiles are the files to update the version in before tagging, the paths are relative to the root
+	// of the repository, see BumpVersion.
+	BumpFiles []string
+	// BumpMessage is the template of the message of the commit of the version files, DefaultBumpMessage if empty.
+	BumpMessage string
+	// Replacements are the rules writing the version into the files, they are set in the configuration file only.
+	Replacements []*Replacement
+	// Hooks are the shell commands to run at the stages of the release.
+	Hooks Hooks
+}
+
+type setting struct {
+	name   string
+	gitKey string
+	env    string
+	set    func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{"prefix", "bumptag.prefix", "BUMPTAG_PREFIX", func(c *Config, value string) error {
+		c.Prefix = value
+		return nil
+	}},
+	{"level", "bumptag.level", "BUMPTAG_LEVEL", func(c *Config, value string) (err error) {
+		c.Level, err = ParseBumpLevel(value)
+		return err
+	}},
+	{"strategy", "bumptag.strategy", "BUMPTAG_STRATEGY", func(c *Config, value string) error {
+		c.Strategy = value
+		return ValidateStrategy(value)
+	}},
+	{"remote", "bumptag.remote", "BUMPTAG_REMOTE", func(c *Config, value string) error {
+		c.Remote = value
+		return nil
+	}},
+	{"sign", "bumptag.sign", "BUMPTAG_SIGN", func(c *Config, value string) (err error) {
+		c.Sign, err = parseBool(value)
+		return err
+	}},
+	{"sign-key", "bumptag.signKey", "BUMPTAG_SIGN_KEY", func(c *Config, value string) error {
+		c.SignKey = value
+		return nil
+	}},
+	{"auto-push", "bumptag.autoPush", "BUMPTAG_AUTO_PUSH", func(c *Config, value string) (err error) {
+		c.AutoPush, err = parseBool(value)
+		return err
+	}},
+	{"auto", "bumptag.auto", "BUMPTAG_AUTO", func(c *Config, value string) (err error) {
+		c.Auto, err = parseBool(value)
+		return err
+	}},
+	{"editor", "bumptag.editor", "BUMPTAG_EDITOR", func(c *Config, value string) error {
+		c.Editor = value
+		return nil
+	}},
+	{"template-file", "bumptag.templateFile", "BUMPTAG_TEMPLATE_FILE", func(c *Config, value string) error {
+		c.TemplateFile = value
+		return nil
+	}},
+	{"template", "bumptag.template", "BUMPTAG_TEMPLATE", func(c *Config, value string) error {
+		c.Template = value
+		return nil
+	}},
+	{"group", "bumptag.group", "BUMPTAG_GROUP", func(c *Config, value string) (err error) {
+		c.Group, err = parseBool(value)
+		return err
+	}},
+	{"changelog-file", "bumptag.changelogFile", "BUMPTAG_CHANGELOG_FILE", func(c *Config, value string) error {
+		c.ChangelogFile = value
+		return nil
+	}},
+	{"release", "bumptag.release", "BUMPTAG_RELEASE", func(c *Config, value string) error {
+		c.Release = value
+		if len(value) == 0 {
+			return nil
+		}
+		return ValidateForge(value)
+	}},
+	{"release-url", "bumptag.releaseUrl", "BUMPTAG_RELEASE_URL", func(c *Config, value string) error {
+		c.ReleaseURL = value
+		return nil
+	}},
+	{"release-project", "bumptag.releaseProject", "BUMPTAG_RELEASE_PROJECT", func(c *Config, value string) error {
+		c.ReleaseProject = value
+		return nil
+	}},
+	{"release-assets", "bumptag.releaseAssets", "BUMPTAG_RELEASE_ASSETS", func(c *Config, value string) error {
+		c.ReleaseAssets = splitList(value)
+		return nil
+	}},
+	{"branches", "bumptag.branches", "BUMPTAG_BRANCHES", func(c *Config, value string) error {
+		c.Branches = splitList(value)
+		return nil
+	}},
+	{"skip-checks", "bumptag.skipChecks", "BUMPTAG_SKIP_CHECKS", func(c *Config, value string) error {
+		c.SkipChecks = splitList(value)
+		for _, name := range c.SkipChecks {
+			if err := ValidateCheck(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{"bump-files", "bumptag.bumpFiles", "BUMPTAG_BUMP_FILES", func(c *Config, value string) error {
+		c.BumpFiles = splitList(value)
+		return nil
+	}},
+	{"bump-message", "bumptag.bumpMessage", "BUMPTAG_BUMP_MESSAGE", func(c *Config, value string) error {
+		if _, err := ParseTemplate(value); err != nil {
+			return err
+		}
+		c.BumpMessage = value
+		return nil
+	}},
+	{HookPreBump, "bumptag.preBump", "BUMPTAG_PRE_BUMP", func(c *Config, value string) error {
+		c.Hooks.PreBump = value
+		return nil
+	}},
+	{HookPreTag, "bumptag.preTag", "BUMPTAG_PRE_TAG", func(c *Config, value string) error {
+		c.Hooks.PreTag = value
+		return nil
+	}},
+	{HookPostTag, "bumptag.postTag", "BUMPTAG_POST_TAG", func(c *Config, value string) error {
+		c.Hooks.PostTag = value
+		return nil
+	}},
+	{HookPostPush, "bumptag.postPush", "BUMPTAG_POST_PUSH", func(c *Config, value string) error {
+		c.Hooks.PostPush = value
+		return nil
+	}},
+	sectionSetting(SectionBreakingChanges, "section-breaking-changes",
+		"bumptag.sectionBreakingChanges", "BUMPTAG_SECTION_BREAKING_CHANGES"),
+	sectionSetting(SectionFeatures, "section-features", "bumptag.sectionFeatures", "BUMPTAG_SECTION_FEATURES"),
+	sectionSetting(SectionBugFixes, "section-bug-fixes", "bumptag.sectionBugFixes", "BUMPTAG_SECTION_BUG_FIXES"),
+	sectionSetting(SectionPerformance, "section-performance",
+		"bumptag.sectionPerformance", "BUMPTAG_SECTION_PERFORMANCE"),
+}
+
+// sectionSetting returns the setting of the pattern of the change log section.
+func sectionSetting(title, name, gitKey, env string) setting {
+	return setting{name, gitKey, env, func(c *Config, value string) error {
+		return c.setSectionPattern(title, value)
+	}}
+}
+
+// setSectionPattern replaces the pattern of the section, the empty pattern removes the section.
+// The sections are kept in the order of DefaultSections.
+func (c *Config) setSectionPattern(title, pattern string) error {
+	var re *regexp.Regexp
+	if len(pattern) > 0 {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return err
+		}
+	}
+	var sections []*Section
+	for _, d := range DefaultSections() {
+		if d.Title == title {
+			if re != nil {
+				sections = append(sections, &Section{Title: title, Pattern: re})
+			}
+			continue
+		}
+		for _, s := range c.Sections {
+			if s.Title == d.Title {
+				sections = append(sections, s)
+			}
+		}
+	}
+	c.Sections = sections
+	return nil
+}
+
+// splitList splits the comma separated list and drops the empty items.
+func splitList(value string) []string {
+	var res []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// parseBool parses the boolean values like git does: true, yes, on, 1 or false, no, off, 0.
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "on":
+		return true, nil
+	case "no", "off":
+		return false, nil
+	}
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean value '%s'", value)
+	}
+	return v, nil
+}
+
+// DefaultConfig returns the configuration used if nothing is configured.
+func DefaultConfig() *Config {
+	return &Config{
+		Prefix:   TagPrefix,
+		Level:    BumpMinor,
+		Strategy: StrategyDescribe,
+		Sections: DefaultSections(),
+	}
+}
+
+// decodeConfigFile parses the YAML or TOML configuration file into the values of the settings.
+func decodeConfigFile(filename string, data []byte) (map[string]string, error) {
+	raw := map[string]interface{}{}
+	var err error
+	if filepath.Ext(filename) == ".toml" {
+		err = toml.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(raw))
+	for name, value := range raw {
+		if name == replaceSetting {
+			// the replacements are decoded by decodeReplacements
+			continue
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("the setting '%s' must be a string, a number or a boolean", name)
+		case nil:
+			values[name] = ""
+		default:
+			values[name] = fmt.Sprint(value)
+		}
+	}
+	return values, nil
+}
+
+// replaceSetting is the list of the replacements in the configuration file.
+const replaceSetting = "replace"
+
+// decodeReplacements parses the replacements of the YAML or TOML configuration file.
+func decodeReplacements(filename string, data []byte) ([]*Replacement, error) {
+	var raw struct {
+		Replace []map[string]string `yaml:"replace" toml:"replace"`
+	}
+	var err error
+	if filepath.Ext(filename) == ".toml" {
+		_, err = toml.Decode(string(data), &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var res []*Replacement
+	for _, fields := range raw.Replace {
+		for key := range fields {
+			if key != "file" && key != "pattern" && key != "replacement" {
+				return nil, fmt.Errorf("unknown field '%s' of the replacement", key)
+			}
+		}
+		rp, err := NewReplacement(fields["file"], fields["pattern"], fields["replacement"])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, rp)
+	}
+	return res, nil
+}
+
+// readConfigFile returns the values of the settings and the replacements from the configuration file and its name,
+// the values are empty if the repository has no configuration file.
+func (r *Repo) readConfigFile() (map[string]string, []*Replacement, string, error) {
+	root, err := r.Backend.Root()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	for _, name := range ConfigFiles {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, "", err
+		}
+		values, err := decodeConfigFile(name, data)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("cannot parse the config file '%s': %w", name, err)
+		}
+		for key := range values {
+			if !isKnownSetting(key) {
+				return nil, nil, "", fmt.Errorf("unknown setting '%s' in the config file '%s'", key, name)
+			}
+		}
+		replacements, err := decodeReplacements(name, data)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("invalid setting 'replace' in the config file '%s': %w", name, err)
+		}
+		return values, replacements, name, nil
+	}
+	return nil, nil, "", nil
+}
+
+func isKnownSetting(name string) bool {
+	for _, s := range settings {
+		if s.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadConfig merges the configuration of the repository, see Config.
+func (r *Repo) LoadConfig() (*Config, error) {
+	c := DefaultConfig()
+	c.Sign = r.signTags()
+
+	values, replacements, filename, err := r.readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	c.Replacements = replacements
+	for _, s := range settings {
+		if value, ok := values[s.name]; ok {
+			if err := s.set(c, value); err != nil {
+				return nil, fmt.Errorf("invalid setting '%s' in the config file '%s': %w", s.name, filename, err)
+			}
+		}
+		if value, err := r.Backend.Config(s.gitKey); err == nil {
+			if err := s.set(c, value); err != nil {
+				return nil, fmt.Errorf("invalid git config option '%s': %w", s.gitKey, err)
+			}
+		}
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(c, value); err != nil {
+				return nil, fmt.Errorf("invalid environment variable '%s': %w", s.env, err)
+			}
+		}
+	}
+	if len(c.Template) == 0 && len(c.TemplateFile) > 0 {
+		if err := r.readTemplateFile(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// readTemplateFile reads the template from the template file relative to the root of the repository.
+func (r *Repo) readTemplateFile(c *Config) error {
+	filename := c.TemplateFile
+	if !filepath.IsAbs(filename) {
+		root, err := r.Backend.Root()
+		if err != nil {
+			return err
+		}
+		filename = filepath.Join(root, filename)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("cannot read the template file '%s': %w", c.TemplateFile, err)
+	}
+	c.Template = string(data)
+	return nil
+}
@@ -0,0 +1,306 @@
+package bumptag
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBool(t *testing.T) {
+	for _, value := range []string{"true", "yes", "On", "1"} {
+		v, err := parseBool(value)
+		assert.NoError(t, err)
+		assert.True(t, v, value)
+	}
+	for _, value := range []string{"false", "no", "OFF", "0"} {
+		v, err := parseBool(value)
+		assert.NoError(t, err)
+		assert.False(t, v, value)
+	}
+	_, err := parseBool("test-value")
+	assert.EqualError(t, err, "invalid boolean value 'test-value'")
+}
+
+func TestDecodeConfigFile(t *testing.T) {
+	values, err := decodeConfigFile(".bumptag.yaml", []byte("prefix: release-\nsign: true\nlevel: patch\nremote:\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"prefix": "release-", "sign": "true", "level": "patch", "remote": ""}, values)
+
+	values, err = decodeConfigFile(".bumptag.toml", []byte("prefix = \"release-\"\nauto-push = false\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"prefix": "release-", "auto-push": "false"}, values)
+
+	_, err = decodeConfigFile(".bumptag.yaml", []byte("prefix:\n  - v\n"))
+	assert.EqualError(t, err, "the setting 'prefix' must be a string, a number or a boolean")
+
+	_, err = decodeConfigFile(".bumptag.toml", []byte("prefix = "))
+	assert.Error(t, err)
+}
+
+func TestLoadConfig(t *testing.T) {
+	_, repo := prepareGit(t)
+	for _, s := range settings {
+		if _, ok := os.LookupEnv(s.env); ok {
+			t.Setenv(s.env, "")
+			assert.NoError(t, os.Unsetenv(s.env))
+		}
+	}
+
+	c, err := repo.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultConfig(), c)
+
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte("prefix: release-\nlevel: patch\nremote: upstream\nauto: yes\n"), 0o600))
+	_, err = git("", "config", "--local", "bumptag.level", "major")
+	assert.NoError(t, err)
+	_, err = git("", "config", "--local", "bumptag.autoPush", "true")
+	assert.NoError(t, err)
+	_, err = git("", "config", "--local", "commit.gpgsign", "true")
+	assert.NoError(t, err)
+	t.Setenv("BUMPTAG_REMOTE", "test-remote")
+	t.Setenv("BUMPTAG_SIGN", "false")
+
+	c, err = repo.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, &Config{
+		Prefix:   "release-",
+		Level:    BumpMajor,
+		Strategy: StrategyDescribe,
+		Remote:   "test-remote",
+		Sign:     false,
+		AutoPush: true,
+		Auto:     true,
+		Sections: DefaultSections(),
+	}, c)
+
+	t.Setenv("BUMPTAG_STRATEGY", "test-strategy")
+	_, err = repo.LoadConfig()
+	assert.EqualError(t, err, "invalid environment variable 'BUMPTAG_STRATEGY': unknown strategy 'test-strategy'")
+	assert.NoError(t, os.Unsetenv("BUMPTAG_STRATEGY"))
+
+	_, err = git("", "config", "--local", "bumptag.autoPush", "test-value")
+	assert.NoError(t, err)
+	_, err = repo.LoadConfig()
+	assert.EqualError(t, err, "invalid git config option 'bumptag.autoPush': invalid boolean value 'test-value'")
+
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte("level: huge\n"), 0o600))
+	_, err = repo.LoadConfig()
+	assert.EqualError(t, err, "invalid setting 'level' in the config file '.bumptag.yaml': unknown bump level 'huge'")
+
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte("prefx: v\n"), 0o600))
+	_, err = repo.LoadConfig()
+	assert.EqualError(t, err, "unknown setting 'prefx' in the config file '.bumptag.yaml'")
+
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte("prefix: [\n"), 0o600))
+	_, err = repo.LoadConfig()
+	assert.Error(t, err)
+}
+
+func TestLoadConfigTemplate(t *testing.T) {
+	_, repo := prepareGit(t)
+	for _, s := range settings {
+		if _, ok := os.LookupEnv(s.env); ok {
+			t.Setenv(s.env, "")
+			assert.NoError(t, os.Unsetenv(s.env))
+		}
+	}
+
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte("template-file: .github/tag.tmpl\n"), 0o600))
+	_, err := repo.LoadConfig()
+	assert.ErrorContains(t, err, "cannot read the template file '.github/tag.tmpl': ")
+
+	assert.NoError(t, os.Mkdir(".github", 0o700))
+	assert.NoError(t, os.WriteFile(".github/tag.tmpl", []byte("Release {{.TagName}}"), 0o600))
+	t.Chdir(".github")
+	c, err := repo.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, ".github/tag.tmpl", c.TemplateFile)
+	assert.Equal(t, "Release {{.TagName}}", c.Template)
+
+	t.Setenv("BUMPTAG_TEMPLATE", "Version {{.Version}}")
+	c, err = repo.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "Version {{.Version}}", c.Template)
+}
+
+func TestLoadConfigSections(t *testing.T) {
+	_, repo := prepareGit(t)
+	for _, s := range settings {
+		if _, ok := os.LookupEnv(s.env); ok {
+			t.Setenv(s.env, "")
+			assert.NoError(t, os.Unsetenv(s.env))
+		}
+	}
+
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte("group: true\nsection-features: '^(feat|add)'\n"+
+		"section-performance: ''\n"), 0o600))
+	_, err := git("", "config", "--local", "bumptag.sectionBugFixes", "")
+	assert.NoError(t, err)
+	t.Setenv("BUMPTAG_SECTION_BUG_FIXES", "^fix")
+	c, err := repo.LoadConfig()
+	assert.NoError(t, err)
+	assert.True(t, c.Group)
+	var titles []string
+	for _, s := range c.Sections {
+		titles = append(titles, s.Title)
+	}
+	assert.Equal(t, []string{SectionBreakingChanges, SectionFeatures, SectionBugFixes, SectionOther}, titles)
+	assert.Equal(t, "^(feat|add)", c.Sections[1].Pattern.String())
+	assert.Equal(t, "^fix", c.Sections[2].Pattern.String())
+
+	t.Setenv("BUMPTAG_SECTION_FEATURES", "(")
+	_, err = repo.LoadConfig()
+	assert.ErrorContains(t, err, "invalid environment variable 'BUMPTAG_SECTION_FEATURES': error parsing regexp")
+}
+
+func TestLoadConfigRelease(t *testing.T) {
+	_, repo := prepareGit(t)
+	for _, s := range settings {
+		if _, ok := os.LookupEnv(s.env); ok {
+			t.Setenv(s.env, "")
+			assert.NoError(t, os.Unsetenv(s.env))
+		}
+	}
+
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte("release: gitea\n"+
+		"release-url: https://git.example.com/api/v1\nrelease-assets: 'dist/*.tar.gz, dist/*.zip,'\n"), 0o600))
+	_, err := git("", "config", "--local", "bumptag.releaseProject", "owner/repo")
+	assert.NoError(t, err)
+	c, err := repo.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, ForgeGitea, c.Release)
+	assert.Equal(t, "https://git.example.com/api/v1", c.ReleaseURL)
+	assert.Equal(t, "owner/repo", c.ReleaseProject)
+	assert.Equal(t, []string{"dist/*.tar.gz", "dist/*.zip"}, c.ReleaseAssets)
+
+	t.Setenv("BUMPTAG_RELEASE", "bitbucket")
+	_, err = repo.LoadConfig()
+	assert.EqualError(t, err, "invalid environment variable 'BUMPTAG_RELEASE': unknown forge 'bitbucket'")
+}
+
+func TestLoadConfigChecks(t *testing.T) {
+	_, repo := prepareGit(t)
+	for _, s := range settings {
+		if _, ok := os.LookupEnv(s.env); ok {
+			t.Setenv(s.env, "")
+			assert.NoError(t, os.Unsetenv(s.env))
+		}
+	}
+
+	assert.NoError(t, os.WriteFile(".bumptag.toml", []byte("branches = \"main, release/*\"\n"), 0o600))
+	t.Setenv("BUMPTAG_SKIP_CHECKS", "behind,tag")
+	c, err := repo.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"main", "release/*"}, c.Branches)
+	assert.Equal(t, []string{CheckBehind, CheckTag}, c.SkipChecks)
+
+	t.Setenv("BUMPTAG_SKIP_CHECKS", "behind,test-check")
+	_, err = repo.LoadConfig()
+	assert.EqualError(t, err, "invalid environment variable 'BUMPTAG_SKIP_CHECKS': unknown check 'test-check'")
+}
+
+func TestLoadConfigBumpFiles(t *testing.T) {
+	_, repo := prepareGit(t)
+	for _, s := range settings {
+		if _, ok := os.LookupEnv(s.env); ok {
+			t.Setenv(s.env, "")
+			assert.NoError(t, os.Unsetenv(s.env))
+		}
+	}
+
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte("bump-files: VERSION, package.json\n"), 0o600))
+	t.Setenv("BUMPTAG_BUMP_MESSAGE", "chore: release {{.TagName}}")
+	c, err := repo.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"VERSION", "package.json"}, c.BumpFiles)
+	assert.Equal(t, "chore: release {{.TagName}}", c.BumpMessage)
+
+	t.Setenv("BUMPTAG_BUMP_MESSAGE", "{{.TagName")
+	_, err = repo.LoadConfig()
+	assert.Error(t, err)
+}
+
+func TestLoadConfigReplacements(t *testing.T) {
+	_, repo := prepareGit(t)
+
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte(`prefix: v
+replace:
+  - file: Dockerfile
+    pattern: '(ARG VERSION=)\S+'
+    replacement: '${1}{{.Version}}'
+`), 0o600))
+	c, err := repo.LoadConfig()
+	assert.NoError(t, err)
+	if assert.Len(t, c.Replacements, 1) {
+		assert.Equal(t, "Dockerfile", c.Replacements[0].File)
+		assert.Equal(t, `(ARG VERSION=)\S+`, c.Replacements[0].Pattern.String())
+	}
+	assert.NoError(t, os.Remove(".bumptag.yaml"))
+
+	assert.NoError(t, os.WriteFile(".bumptag.toml", []byte(`[[replace]]
+file = "README.md"
+pattern = 'app@v\S+'
+replacement = "app@{{.TagName}}"
+`), 0o600))
+	c, err = repo.LoadConfig()
+	assert.NoError(t, err)
+	if assert.Len(t, c.Replacements, 1) {
+		assert.Equal(t, "README.md", c.Replacements[0].File)
+	}
+
+	assert.NoError(t, os.WriteFile(".bumptag.toml", []byte("[[replace]]\nfile = \"README.md\"\nregex = 'v\\S+'\n"), 0o600))
+	_, err = repo.LoadConfig()
+	assert.EqualError(
+		t,
+		err,
+		"invalid setting 'replace' in the config file '.bumptag.toml': unknown field 'regex' of the replacement",
+	)
+}
+
+func TestLoadConfigHooks(t *testing.T) {
+	_, repo := prepareGit(t)
+	for _, s := range settings {
+		if _, ok := os.LookupEnv(s.env); ok {
+			t.Setenv(s.env, "")
+			assert.NoError(t, os.Unsetenv(s.env))
+		}
+	}
+
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte("pre-tag: go test ./...\npost-push: make release\n"), 0o600))
+	_, err := git("", "config", "--local", "bumptag.preBump", "go generate ./...")
+	assert.NoError(t, err)
+	t.Setenv("BUMPTAG_POST_PUSH", "")
+	c, err := repo.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, Hooks{PreBump: "go generate ./...", PreTag: "go test ./..."}, c.Hooks)
+}
+
+func TestLoadConfigSign(t *testing.T) {
+	_, repo := prepareGit(t)
+	for _, s := range settings {
+		if _, ok := os.LookupEnv(s.env); ok {
+			t.Setenv(s.env, "")
+			assert.NoError(t, os.Unsetenv(s.env))
+		}
+	}
+
+	_, err := git("", "config", "--local", "commit.gpgsign", "true")
+	assert.NoError(t, err)
+	c, err := repo.LoadConfig()
+	assert.NoError(t, err)
+	assert.False(t, c.Sign, "tag.gpgSign wins over commit.gpgsign")
+
+	_, err = git("", "config", "--local", "--unset", "tag.gpgSign")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte("sign-key: ~/.ssh/id_ed25519.pub\n"), 0o600))
+	c, err = repo.LoadConfig()
+	assert.NoError(t, err)
+	assert.True(t, c.Sign)
+	assert.Equal(t, "~/.ssh/id_ed25519.pub", c.SignKey)
+
+	t.Setenv("BUMPTAG_SIGN_KEY", "ABCDEF01")
+	c, err = repo.LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "ABCDEF01", c.SignKey)
+}
@@ -0,0 +1,70 @@
+package bumptag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	conventionalSubjectRe = regexp.MustCompile(`^(\w+)(?:\(([^()]*)\))?(!)?:\s+(.+)$`)
+	breakingChangeRe      = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:\s`)
+)
+
+// ConventionalCommit is a commit message parsed according to https://www.conventionalcommits.org
+type ConventionalCommit struct {
+	// Type is in lower case, e.g. `feat`
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+}
+
+// ParseConventionalCommit returns nil if the commit does not follow the Conventional Commits.
+func ParseConventionalCommit(c *Commit) *ConventionalCommit {
+	parts := conventionalSubjectRe.FindStringSubmatch(c.Subject)
+	if parts == nil {
+		return nil
+	}
+	return &ConventionalCommit{
+		Type:        strings.ToLower(parts[1]),
+		Scope:       parts[2],
+		Description: parts[4],
+		Breaking:    len(parts[3]) > 0 || breakingChangeRe.MatchString(c.Body),
+	}
+}
+
+// AutoBumpLevel detects the bump level by the Conventional Commits messages.
+// A breaking change increments the MAJOR version (the MINOR for 0.x versions),
+// a new feature increments the MINOR version and everything else increments the PATCH version.
+// The reason describes the commit which defines the level.
+func AutoBumpLevel(v *Version, commits []*Commit) (BumpLevel, string) {
+	level := BumpPatch
+	reason := "no features or breaking changes"
+	var trigger *Commit
+	for _, c := range commits {
+		cc := ParseConventionalCommit(c)
+		if cc == nil {
+			continue
+		}
+		switch {
+		case cc.Breaking && level < BumpMajor:
+			level, trigger = BumpMajor, c
+			reason = "breaking change"
+		case cc.Type == "feat" && level < BumpMinor:
+			level, trigger = BumpMinor, c
+			reason = "new feature"
+		case cc.Type == "fix" && trigger == nil:
+			trigger = c
+			reason = "bug fix"
+		}
+	}
+	if trigger != nil {
+		reason = fmt.Sprintf("%s in '%s'", reason, trigger)
+	}
+	if level == BumpMajor && v.Major == 0 {
+		level = BumpMinor
+		reason += ", the 0.x version"
+	}
+	return level, reason
+}
@@ -0,0 +1,60 @@
+package bumptag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	cc := ParseConventionalCommit(&Commit{Subject: "Update README.md"})
+	assert.Nil(t, cc)
+
+	cc = ParseConventionalCommit(&Commit{Subject: "feat(cli): add --auto flag"})
+	assert.Equal(t, &ConventionalCommit{
+		Type:        "feat",
+		Scope:       "cli",
+		Description: "add --auto flag",
+	}, cc)
+
+	cc = ParseConventionalCommit(&Commit{Subject: "refactor!: drop the old API"})
+	assert.NotNil(t, cc)
+	assert.True(t, cc.Breaking)
+
+	cc = ParseConventionalCommit(&Commit{Subject: "fix: test", Body: "Some text\n\nBREAKING-CHANGE: the output is changed"})
+	assert.NotNil(t, cc)
+	assert.Equal(t, "fix", cc.Type)
+	assert.True(t, cc.Breaking)
+}
+
+func TestAutoBumpLevel(t *testing.T) {
+	commits := []*Commit{
+		{Hash: "1111111", Subject: "docs: update README.md"},
+		{Hash: "2222222", Subject: "Some commit"},
+	}
+	level, reason := AutoBumpLevel(mustVersion("1.0.0"), commits)
+	assert.Equal(t, BumpPatch, level)
+	assert.Equal(t, "no features or breaking changes", reason)
+
+	commits = append(commits, &Commit{Hash: "3333333", Subject: "fix: test"})
+	level, reason = AutoBumpLevel(mustVersion("1.0.0"), commits)
+	assert.Equal(t, BumpPatch, level)
+	assert.Equal(t, "bug fix in '3333333 fix: test'", reason)
+
+	commits = append(commits, &Commit{Hash: "4444444", Subject: "feat(cli): test"})
+	level, reason = AutoBumpLevel(mustVersion("1.0.0"), commits)
+	assert.Equal(t, BumpMinor, level)
+	assert.Equal(t, "new feature in '4444444 feat(cli): test'", reason)
+
+	commits = append(commits, &Commit{Hash: "5555555", Subject: "fix: test", Body: "BREAKING CHANGE: test"})
+	level, reason = AutoBumpLevel(mustVersion("1.0.0"), commits)
+	assert.Equal(t, BumpMajor, level)
+	assert.Equal(t, "breaking change in '5555555 fix: test'", reason)
+
+	level, reason = AutoBumpLevel(mustVersion("0.3.0"), commits)
+	assert.Equal(t, BumpMinor, level)
+	assert.Equal(t, "breaking change in '5555555 fix: test', the 0.x version", reason)
+
+	level, _ = AutoBumpLevel(mustVersion("1.0.0"), nil)
+	assert.Equal(t, BumpPatch, level)
+}
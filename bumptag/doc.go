@@ -0,0 +1,37 @@
+// Package bumptag finds the latest tag of a git repository, increments it and creates a new tag with a change log.
+//
+// The package is the engine of the bumptag tool and can be used to release from Go programs:
+//
+//	repo, err := bumptag.OpenRepo(".", bumptag.BackendAuto)
+//	if err != nil {
+//		return err
+//	}
+//	restore, err := repo.Backend.Setup()
+//	if err != nil {
+//		return err
+//	}
+//	defer restore()
+//
+//	cfg, err := repo.LoadConfig()
+//	if err != nil {
+//		return err
+//	}
+//	repo.TagPrefix = cfg.Prefix
+//
+//	bumper := bumptag.NewBumper(repo)
+//	bumper.Level = cfg.Level
+//	bumper.Auto = true
+//	release, err := bumper.Prepare(repo.RootModule())
+//	if err != nil {
+//		return err
+//	}
+//	tagger := bumptag.NewTagger(repo)
+//	tagger.Sign = cfg.Sign
+//	tagger.SignKey = cfg.SignKey
+//	tagger.Remote = cfg.Remote
+//	if err := tagger.Tag(release); err != nil {
+//		return err
+//	}
+//	_, err = tagger.Push(release)
+//	return err
+package bumptag
@@ -0,0 +1,49 @@
+package bumptag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The errors of the common problems, the returned errors wrap them with the details, use errors.Is to check.
+var (
+	// ErrDirtyTree means the working tree has uncommitted changes of the tracked files.
+	ErrDirtyTree = errors.New("the working tree has uncommitted changes")
+	// ErrTagExists means the tag to create already exists.
+	ErrTagExists = errors.New("the tag already exists")
+	// ErrInvalidVersion means the version is not a Semantic Version or cannot be used for the release.
+	ErrInvalidVersion = errors.New("invalid version")
+	// ErrNoRemote means the remote to push the tag to is not found.
+	ErrNoRemote = errors.New("no remote")
+	// ErrCheckFailed means a pre-flight check failed, see CheckError.
+	ErrCheckFailed = errors.New("the check failed")
+	// ErrGitFailed means the git command failed, see GitError.
+	ErrGitFailed = errors.New("git command failed")
+)
+
+// GitError is the failure of the git command, it matches ErrGitFailed.
+type GitError struct {
+	Args []string
+	// Stderr is the error output of the command.
+	Stderr string
+	Err    error
+}
+
+// Error returns the command, the exit status and the first line of the error output.
+func (e *GitError) Error() string {
+	text := fmt.Sprintf("command '%s' failed: %s", strings.Join(e.Args, " "), e.Err)
+	if line := strings.TrimSpace(strings.SplitN(e.Stderr, "\n", 2)[0]); len(line) > 0 {
+		text += ": " + line
+	}
+	return text
+}
+
+func (e *GitError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, ErrGitFailed) true.
+func (e *GitError) Is(target error) bool {
+	return target == ErrGitFailed
+}
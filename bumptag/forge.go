@@ -0,0 +1,360 @@
+package bumptag
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// The names of the forges, the git hostings with the releases API.
+const (
+	ForgeGitHub = "github"
+	ForgeGitea  = "gitea"
+	ForgeGitLab = "gitlab"
+)
+
+// tokenEnvs are the environment variables with the API tokens of the forges, BUMPTAG_RELEASE_TOKEN wins.
+var tokenEnvs = map[string]string{
+	ForgeGitHub: "GITHUB_TOKEN",
+	ForgeGitea:  "GITEA_TOKEN",
+	ForgeGitLab: "GITLAB_TOKEN",
+}
+
+// ValidateForge returns an error if the name is not a forge.
+func ValidateForge(name string) error {
+	if _, ok := tokenEnvs[name]; !ok {
+		return fmt.Errorf("unknown forge '%s'", name)
+	}
+	return nil
+}
+
+// Publisher creates the releases of the pushed tags on GitHub, Gitea or GitLab.
+type Publisher struct {
+	Repo *Repo
+	// Forge is the kind of the git hosting: github, gitea or gitlab.
+	Forge string
+	// URL is the base URL of the API, e.g. `https://api.github.com`, it is detected from the URL of the remote if empty.
+	URL string
+	// Project is the repository on the forge, e.g. `sv-tools/bumptag`, it is detected from the URL of the remote
+	// if empty.
+	Project string
+	// Token is the API token, the BUMPTAG_RELEASE_TOKEN or the GITHUB_TOKEN, GITEA_TOKEN, GITLAB_TOKEN environment
+	// variable by default.
+	Token string
+	// Assets are the glob patterns of the files to upload to the release.
+	Assets []string
+	Client *http.Client
+}
+
+// NewPublisher returns a publisher to the forge with the token from the environment variables,
+// a missing token is an error, so the tag is not created and pushed without its release.
+func NewPublisher(repo *Repo, forge string) (*Publisher, error) {
+	if err := ValidateForge(forge); err != nil {
+		return nil, err
+	}
+	env := tokenEnvs[forge]
+	token := os.Getenv("BUMPTAG_RELEASE_TOKEN")
+	if len(token) == 0 {
+		token = os.Getenv(env)
+	}
+	if len(token) == 0 {
+		return nil, errNoToken(forge)
+	}
+	return &Publisher{
+		Repo:   repo,
+		Forge:  forge,
+		Token:  token,
+		Client: &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+func errNoToken(forge string) error {
+	return fmt.Errorf("the API token is not set, set BUMPTAG_RELEASE_TOKEN or %s", tokenEnvs[forge])
+}
+
+var scpLikeURLRe = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// parseRemoteURL returns the base URL of the web server and the project path of the remote URL,
+// e.g. `git@github.com:sv-tools/bumptag.git` -> `https://github.com`, `sv-tools/bumptag`.
+func parseRemoteURL(remoteURL string) (string, string, error) {
+	if !strings.Contains(remoteURL, "://") {
+		parts := scpLikeURLRe.FindStringSubmatch(remoteURL)
+		if parts == nil {
+			return "", "", fmt.Errorf("cannot parse the remote URL '%s'", remoteURL)
+		}
+		remoteURL = "ssh://" + parts[1] + "/" + parts[2]
+	}
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return "", "", err
+	}
+	project := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if len(u.Hostname()) == 0 || len(project) == 0 {
+		return "", "", fmt.Errorf("cannot parse the remote URL '%s'", remoteURL)
+	}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		return u.Scheme + "://" + u.Host, project, nil
+	}
+	return "https://" + u.Hostname(), project, nil
+}
+
+// apiURL returns the base URL of the API of the forge on the web server.
+func apiURL(forge, server string) string {
+	switch {
+	case forge == ForgeGitHub && server == "https://github.com":
+		return "https://api.github.com"
+	case forge == ForgeGitHub:
+		return server + "/api/v3"
+	case forge == ForgeGitea:
+		return server + "/api/v1"
+	}
+	return server + "/api/v4"
+}
+
+// resolve detects the URL of the API and the project from the URL of the remote if they are not set.
+func (p *Publisher) resolve(remote string) (string, string, error) {
+	if len(p.URL) > 0 && len(p.Project) > 0 {
+		return strings.TrimSuffix(p.URL, "/"), p.Project, nil
+	}
+	remoteURL, err := p.Repo.Backend.Config("remote." + remote + ".url")
+	if err != nil {
+		return "", "", fmt.Errorf("cannot find the URL of the remote '%s': %w", remote, err)
+	}
+	server, project, err := parseRemoteURL(remoteURL)
+	if err != nil {
+		return "", "", err
+	}
+	base := apiURL(p.Forge, server)
+	if len(p.URL) > 0 {
+		base = strings.TrimSuffix(p.URL, "/")
+	}
+	if len(p.Project) > 0 {
+		project = p.Project
+	}
+	return base, project, nil
+}
+
+// AssetFiles returns the files matching the patterns of the assets, a pattern matching no files is an error.
+func (p *Publisher) AssetFiles() ([]string, error) {
+	var res []string
+	for _, pattern := range p.Assets {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("the asset pattern '%s' matches no files", pattern)
+		}
+		res = append(res, files...)
+	}
+	return res, nil
+}
+
+// Publish creates the release of the tag pushed to the remote with the annotation without the subject line
+// as the release notes, uploads the assets and returns the URL of the release.
+// The pre-releases are marked as such on GitHub and Gitea.
+func (p *Publisher) Publish(r *Release, remote string) (string, error) {
+	if len(p.Token) == 0 {
+		return "", errNoToken(p.Forge)
+	}
+	files, err := p.AssetFiles()
+	if err != nil {
+		return "", err
+	}
+	base, project, err := p.resolve(remote)
+	if err != nil {
+		return "", err
+	}
+	_, notes := splitMessage(r.Annotation)
+	switch p.Forge {
+	case ForgeGitHub:
+		return p.publishGitHub(base, project, r, notes, files)
+	case ForgeGitea:
+		return p.publishGitea(base, project, r, notes, files)
+	case ForgeGitLab:
+		return p.publishGitLab(base, project, r, notes, files)
+	}
+	return "", fmt.Errorf("unknown forge '%s'", p.Forge)
+}
+
+// authorize adds the token to the request in the way the forge expects.
+func (p *Publisher) authorize(req *http.Request) {
+	switch p.Forge {
+	case ForgeGitLab:
+		req.Header.Set("PRIVATE-TOKEN", p.Token)
+	case ForgeGitea:
+		req.Header.Set("Authorization", "token "+p.Token)
+	default:
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+	}
+}
+
+// do sends the request and decodes the JSON response into the result if it is not nil.
+func (p *Publisher) do(method, requestURL, contentType string, body io.Reader, result interface{}) error {
+	req, err := http.NewRequest(method, requestURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	p.authorize(req)
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, requestURL, resp.Status, strings.TrimSpace(string(message)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (p *Publisher) postJSON(requestURL string, data, result interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return p.do(http.MethodPost, requestURL, "application/json", bytes.NewReader(body), result)
+}
+
+// postFile uploads the file as the multipart form field.
+func (p *Publisher) postFile(requestURL, field, filename string, result interface{}) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile(field, filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return p.do(http.MethodPost, requestURL, w.FormDataContentType(), &body, result)
+}
+
+type githubRelease struct {
+	HTMLURL   string `json:"html_url"`
+	UploadURL string `json:"upload_url"`
+}
+
+func (p *Publisher) publishGitHub(base, project string, r *Release, notes string, files []string) (string, error) {
+	var release githubRelease
+	err := p.postJSON(base+"/repos/"+project+"/releases", map[string]interface{}{
+		"tag_name":   r.TagName,
+		"name":       r.TagName,
+		"body":       notes,
+		"prerelease": len(r.Version.PreRelease) > 0,
+	}, &release)
+	if err != nil {
+		return "", err
+	}
+	// the upload URL is a template, e.g. `https://uploads.github.com/repos/o/r/releases/1/assets{?name,label}`
+	uploadURL := release.UploadURL
+	if i := strings.Index(uploadURL, "{"); i >= 0 {
+		uploadURL = uploadURL[:i]
+	}
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return "", err
+		}
+		assetURL := uploadURL + "?name=" + url.QueryEscape(filepath.Base(name))
+		if err := p.do(http.MethodPost, assetURL, "application/octet-stream", bytes.NewReader(data), nil); err != nil {
+			return "", err
+		}
+	}
+	return release.HTMLURL, nil
+}
+
+type giteaRelease struct {
+	ID      int64  `json:"id"`
+	HTMLURL string `json:"html_url"`
+}
+
+func (p *Publisher) publishGitea(base, project string, r *Release, notes string, files []string) (string, error) {
+	var release giteaRelease
+	err := p.postJSON(base+"/repos/"+project+"/releases", map[string]interface{}{
+		"tag_name":   r.TagName,
+		"name":       r.TagName,
+		"body":       notes,
+		"prerelease": len(r.Version.PreRelease) > 0,
+	}, &release)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range files {
+		assetURL := fmt.Sprintf("%s/repos/%s/releases/%d/assets?name=%s",
+			base, project, release.ID, url.QueryEscape(filepath.Base(name)))
+		if err := p.postFile(assetURL, "attachment", name, nil); err != nil {
+			return "", err
+		}
+	}
+	return release.HTMLURL, nil
+}
+
+type gitlabRelease struct {
+	Links struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+}
+
+type gitlabUpload struct {
+	FullPath string `json:"full_path"`
+	URL      string `json:"url"`
+}
+
+// publishGitLab creates the release, the assets are uploaded to the project and linked to the release.
+func (p *Publisher) publishGitLab(base, project string, r *Release, notes string, files []string) (string, error) {
+	projectURL := base + "/projects/" + url.PathEscape(project)
+	var release gitlabRelease
+	err := p.postJSON(projectURL+"/releases", map[string]interface{}{
+		"tag_name":    r.TagName,
+		"name":        r.TagName,
+		"description": notes,
+	}, &release)
+	if err != nil {
+		return "", err
+	}
+	server := strings.TrimSuffix(base, "/api/v4")
+	for _, name := range files {
+		var upload gitlabUpload
+		if err := p.postFile(projectURL+"/uploads", "file", name, &upload); err != nil {
+			return "", err
+		}
+		if len(upload.FullPath) == 0 {
+			return "", errors.New("the upload of the asset '" + name + "' returned no path")
+		}
+		err := p.postJSON(projectURL+"/releases/"+url.PathEscape(r.TagName)+"/assets/links", map[string]string{
+			"name": filepath.Base(name),
+			"url":  server + upload.FullPath,
+		}, nil)
+		if err != nil {
+			return "", err
+		}
+	}
+	return release.Links.Self, nil
+}
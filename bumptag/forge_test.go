@@ -0,0 +1,241 @@
+package bumptag
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	cases := []struct {
+		remoteURL string
+		server    string
+		project   string
+	}{
+		{"git@github.com:sv-tools/bumptag.git", "https://github.com", "sv-tools/bumptag"},
+		{"github.com:sv-tools/bumptag", "https://github.com", "sv-tools/bumptag"},
+		{"ssh://git@gitlab.example.com:2222/group/sub/project.git", "https://gitlab.example.com", "group/sub/project"},
+		{"https://github.com/sv-tools/bumptag.git", "https://github.com", "sv-tools/bumptag"},
+		{"http://localhost:3000/owner/repo/", "http://localhost:3000", "owner/repo"},
+	}
+	for _, c := range cases {
+		server, project, err := parseRemoteURL(c.remoteURL)
+		assert.NoError(t, err, c.remoteURL)
+		assert.Equal(t, c.server, server, c.remoteURL)
+		assert.Equal(t, c.project, project, c.remoteURL)
+	}
+
+	for _, remoteURL := range []string{"/srv/git/repo.git", "https://github.com/"} {
+		_, _, err := parseRemoteURL(remoteURL)
+		assert.Error(t, err, remoteURL)
+	}
+}
+
+func TestAPIURL(t *testing.T) {
+	assert.Equal(t, "https://api.github.com", apiURL(ForgeGitHub, "https://github.com"))
+	assert.Equal(t, "https://git.example.com/api/v3", apiURL(ForgeGitHub, "https://git.example.com"))
+	assert.Equal(t, "https://git.example.com/api/v1", apiURL(ForgeGitea, "https://git.example.com"))
+	assert.Equal(t, "https://git.example.com/api/v4", apiURL(ForgeGitLab, "https://git.example.com"))
+}
+
+func TestNewPublisher(t *testing.T) {
+	t.Setenv("BUMPTAG_RELEASE_TOKEN", "")
+	t.Setenv("GITEA_TOKEN", "gitea-token")
+	p, err := NewPublisher(nil, ForgeGitea)
+	assert.NoError(t, err)
+	assert.Equal(t, "gitea-token", p.Token)
+
+	t.Setenv("BUMPTAG_RELEASE_TOKEN", "release-token")
+	p, err = NewPublisher(nil, ForgeGitea)
+	assert.NoError(t, err)
+	assert.Equal(t, "release-token", p.Token)
+
+	_, err = NewPublisher(nil, "bitbucket")
+	assert.EqualError(t, err, "unknown forge 'bitbucket'")
+	assert.NoError(t, ValidateForge(ForgeGitLab))
+	assert.EqualError(t, ValidateForge("bitbucket"), "unknown forge 'bitbucket'")
+
+	t.Setenv("BUMPTAG_RELEASE_TOKEN", "")
+	t.Setenv("GITEA_TOKEN", "")
+	_, err = NewPublisher(nil, ForgeGitea)
+	assert.EqualError(t, err, "the API token is not set, set BUMPTAG_RELEASE_TOKEN or GITEA_TOKEN")
+}
+
+// writeAsset creates the asset file in a temporary directory and returns its glob pattern.
+func writeAsset(t *testing.T) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "app.tar.gz"), []byte("test-asset"), 0o600))
+	return filepath.Join(dir, "*.tar.gz")
+}
+
+func decodeBody(t *testing.T, r *http.Request) map[string]interface{} {
+	var body map[string]interface{}
+	assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	return body
+}
+
+func readAsset(t *testing.T, r *http.Request, field string) string {
+	file, header, err := r.FormFile(field)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer file.Close()
+	assert.Equal(t, "app.tar.gz", header.Filename)
+	data, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+var testRelease = &Release{
+	Version:    mustVersion("1.3.0-rc.1"),
+	TagName:    "v1.3.0-rc.1",
+	Annotation: "Bump version v1.3.0-rc.1\n\n* abc1234 test",
+}
+
+func TestPublishGitHub(t *testing.T) {
+	var uploaded string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("POST /repos/owner/repo/releases", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		assert.Equal(t, map[string]interface{}{
+			"tag_name":   "v1.3.0-rc.1",
+			"name":       "v1.3.0-rc.1",
+			"body":       "* abc1234 test",
+			"prerelease": true,
+		}, decodeBody(t, r))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"html_url": "https://github.com/owner/repo/releases/tag/v1.3.0-rc.1",
+			"upload_url": "`+server.URL+`/uploads/1/assets{?name,label}"}`)
+	})
+	mux.HandleFunc("POST /uploads/1/assets", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "app.tar.gz", r.URL.Query().Get("name"))
+		assert.Equal(t, "application/octet-stream", r.Header.Get("Content-Type"))
+		data, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		uploaded = string(data)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	p := &Publisher{
+		Forge:   ForgeGitHub,
+		URL:     server.URL + "/",
+		Project: "owner/repo",
+		Token:   "test-token",
+		Assets:  []string{writeAsset(t)},
+	}
+	releaseURL, err := p.Publish(testRelease, "origin")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://github.com/owner/repo/releases/tag/v1.3.0-rc.1", releaseURL)
+	assert.Equal(t, "test-asset", uploaded)
+}
+
+func TestPublishGitea(t *testing.T) {
+	var uploaded string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/repos/owner/repo/releases", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token test-token", r.Header.Get("Authorization"))
+		assert.Equal(t, true, decodeBody(t, r)["prerelease"])
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"id": 7, "html_url": "http://gitea/owner/repo/releases/tag/v1.3.0-rc.1"}`)
+	})
+	mux.HandleFunc("POST /api/v1/repos/owner/repo/releases/7/assets", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "app.tar.gz", r.URL.Query().Get("name"))
+		uploaded = readAsset(t, r, "attachment")
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctrl, cli := mockGit(t)
+	ctrl.EXPECT().
+		Git("", "config", "--get", "remote.origin.url").
+		Return(server.URL+"/owner/repo.git", nil)
+	p := &Publisher{
+		Repo:   NewRepo(cli),
+		Forge:  ForgeGitea,
+		Token:  "test-token",
+		Assets: []string{writeAsset(t)},
+	}
+	releaseURL, err := p.Publish(testRelease, "origin")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://gitea/owner/repo/releases/tag/v1.3.0-rc.1", releaseURL)
+	assert.Equal(t, "test-asset", uploaded)
+}
+
+func TestPublishGitLab(t *testing.T) {
+	var uploaded string
+	var link map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v4/projects/group%2Fproject/releases", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-token", r.Header.Get("PRIVATE-TOKEN"))
+		assert.Equal(t, map[string]interface{}{
+			"tag_name":    "v1.3.0-rc.1",
+			"name":        "v1.3.0-rc.1",
+			"description": "* abc1234 test",
+		}, decodeBody(t, r))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"_links": {"self": "http://gitlab/group/project/-/releases/v1.3.0-rc.1"}}`)
+	})
+	mux.HandleFunc("POST /api/v4/projects/group%2Fproject/uploads", func(w http.ResponseWriter, r *http.Request) {
+		uploaded = readAsset(t, r, "file")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"full_path": "/-/project/1/uploads/abc/app.tar.gz"}`)
+	})
+	mux.HandleFunc(
+		"POST /api/v4/projects/group%2Fproject/releases/v1.3.0-rc.1/assets/links",
+		func(w http.ResponseWriter, r *http.Request) {
+			link = decodeBody(t, r)
+			w.WriteHeader(http.StatusCreated)
+		},
+	)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	p := &Publisher{
+		Forge:   ForgeGitLab,
+		URL:     server.URL + "/api/v4",
+		Project: "group/project",
+		Token:   "test-token",
+		Assets:  []string{writeAsset(t)},
+	}
+	releaseURL, err := p.Publish(testRelease, "origin")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://gitlab/group/project/-/releases/v1.3.0-rc.1", releaseURL)
+	assert.Equal(t, "test-asset", uploaded)
+	assert.Equal(t, map[string]interface{}{
+		"name": "app.tar.gz",
+		"url":  server.URL + "/-/project/1/uploads/abc/app.tar.gz",
+	}, link)
+}
+
+func TestPublishErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = io.WriteString(w, `{"message": "Validation Failed"}`)
+	}))
+	defer server.Close()
+
+	p := &Publisher{Forge: ForgeGitHub, URL: server.URL, Project: "owner/repo"}
+	_, err := p.Publish(testRelease, "origin")
+	assert.EqualError(t, err, "the API token is not set, set BUMPTAG_RELEASE_TOKEN or GITHUB_TOKEN")
+
+	p.Token = "test-token"
+	_, err = p.Publish(testRelease, "origin")
+	assert.EqualError(
+		t,
+		err,
+		"POST "+server.URL+"/repos/owner/repo/releases: 422 Unprocessable Entity: {\"message\": \"Validation Failed\"}",
+	)
+
+	p.Assets = []string{filepath.Join(t.TempDir(), "*.zip")}
+	_, err = p.Publish(testRelease, "origin")
+	assert.EqualError(t, err, "the asset pattern '"+p.Assets[0]+"' matches no files")
+}
@@ -0,0 +1,878 @@
+package bumptag
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+const (
+	shortHashLength = 7
+	gitDateFormat   = "Mon Jan 2 15:04:05 2006 -0700"
+)
+
+var errSigningNotSupported = errors.New("the go backend cannot sign the tags and commits, use '--backend cli'")
+
+// GoGitBackend reads and writes the repository directly without the git binary.
+type GoGitBackend struct {
+	repo *gogit.Repository
+}
+
+// OpenGoGitBackend opens the repository containing the directory.
+func OpenGoGitBackend(dir string) (*GoGitBackend, error) {
+	repo, err := gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the git repository '%s': %w", dir, err)
+	}
+	return &GoGitBackend{repo: repo}, nil
+}
+
+// globRegexp converts the pattern to a regular expression the way git matches the tags,
+// `*` matches any string including `/`.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var res strings.Builder
+	res.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			res.WriteString(".*")
+		case '?':
+			res.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				res.WriteString(regexp.QuoteMeta(pattern[i:]))
+				i = len(pattern)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			res.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			res.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			res.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	res.WriteString("$")
+	return regexp.Compile(res.String())
+}
+
+func matchPattern(pattern, name string) (bool, error) {
+	if len(pattern) == 0 {
+		return true, nil
+	}
+	re, err := globRegexp(pattern)
+	if err != nil {
+		return false, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+	}
+	return re.MatchString(name), nil
+}
+
+func isInPath(name, dir string) bool {
+	return len(dir) == 0 || name == dir || strings.HasPrefix(name, dir+"/")
+}
+
+// splitMessage returns the first paragraph of the commit message joined into one line and the rest of the message.
+func splitMessage(message string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(message), "\n\n", 2)
+	subject := strings.Join(strings.Fields(parts[0]), " ")
+	if len(parts) == 1 {
+		return subject, ""
+	}
+	return subject, strings.TrimSpace(parts[1])
+}
+
+// peel follows the annotated tags to the tagged object.
+func (b *GoGitBackend) peel(hash plumbing.Hash) (plumbing.Hash, error) {
+	for {
+		tag, err := b.repo.TagObject(hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			return hash, nil
+		}
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		hash = tag.Target
+	}
+}
+
+func (b *GoGitBackend) resolve(rev string) (plumbing.Hash, error) {
+	if ref, err := b.repo.Tag(rev); err == nil {
+		return b.peel(ref.Hash())
+	}
+	hash, err := b.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("cannot resolve the revision '%s': %w", rev, err)
+	}
+	return *hash, nil
+}
+
+// tagCommits returns the commits of all tags by their names.
+func (b *GoGitBackend) tagCommits() (map[string]plumbing.Hash, error) {
+	refs, err := b.repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]plumbing.Hash)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		hash, err := b.peel(ref.Hash())
+		if err != nil {
+			return err
+		}
+		res[ref.Name().Short()] = hash
+		return nil
+	})
+	return res, err
+}
+
+func (b *GoGitBackend) reachable(from plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	commits, err := b.repo.Log(&gogit.LogOptions{From: from})
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[plumbing.Hash]bool)
+	err = commits.ForEach(func(c *object.Commit) error {
+		res[c.Hash] = true
+		return nil
+	})
+	return res, err
+}
+
+// touches checks if the commit changes any file in the path except the excluded paths.
+func touches(c *object.Commit, dir string, excludes []string) (bool, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return false, err
+	}
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return false, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return false, err
+		}
+	}
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return false, err
+	}
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if len(name) == 0 || !isInPath(name, dir) {
+				continue
+			}
+			excluded := false
+			for _, exclude := range excludes {
+				excluded = excluded || isInPath(name, exclude)
+			}
+			if !excluded {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (b *GoGitBackend) signature() (*object.Signature, error) {
+	name, err := b.Config("user.name")
+	if err != nil {
+		return nil, err
+	}
+	email, err := b.Config("user.email")
+	if err != nil {
+		return nil, err
+	}
+	return &object.Signature{Name: name, Email: email, When: time.Now()}, nil
+}
+
+func (b *GoGitBackend) Setup() (func(), error) {
+	return func() {}, nil
+}
+
+func (b *GoGitBackend) Root() (string, error) {
+	w, err := b.repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	return w.Filesystem.Root(), nil
+}
+
+// Config looks up the option in the local, global and system configs.
+func (b *GoGitBackend) Config(name string) (string, error) {
+	first, last := strings.Index(name, "."), strings.LastIndex(name, ".")
+	if first <= 0 || last == len(name)-1 {
+		return "", fmt.Errorf("invalid config option '%s'", name)
+	}
+	section, key := name[:first], name[last+1:]
+	var subsection string
+	if first < last {
+		subsection = name[first+1 : last]
+	}
+
+	local, err := b.repo.Config()
+	if err != nil {
+		return "", err
+	}
+	configs := []*config.Config{local}
+	for _, scope := range []config.Scope{config.GlobalScope, config.SystemScope} {
+		if cfg, err := config.LoadConfig(scope); err == nil {
+			configs = append(configs, cfg)
+		}
+	}
+	for _, cfg := range configs {
+		if !cfg.Raw.HasSection(section) {
+			continue
+		}
+		options := cfg.Raw.Section(section).Options
+		if len(subsection) > 0 {
+			s := cfg.Raw.Section(section)
+			if !s.HasSubsection(subsection) {
+				continue
+			}
+			options = s.Subsection(subsection).Options
+		}
+		if options.Has(key) {
+			return options.Get(key), nil
+		}
+	}
+	return "", fmt.Errorf("the config option '%s' is not set", name)
+}
+
+func (b *GoGitBackend) Tags(pattern string) ([]string, error) {
+	tags, err := b.tagCommits()
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for name := range tags {
+		ok, err := matchPattern(pattern, name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func (b *GoGitBackend) MergedTags() ([]string, error) {
+	head, err := b.resolve("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	reachable, err := b.reachable(head)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := b.tagCommits()
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for name, hash := range tags {
+		if reachable[hash] {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// DescribeTag walks the history from HEAD by the commit date and returns the first matching tag.
+func (b *GoGitBackend) DescribeTag(match, exclude string) (string, error) {
+	tags, err := b.tagCommits()
+	if err != nil {
+		return "", err
+	}
+	byCommit := make(map[plumbing.Hash][]string)
+	for name, hash := range tags {
+		matched, err := matchPattern(match, name)
+		if err != nil {
+			return "", err
+		}
+		excluded := false
+		if len(exclude) > 0 {
+			if excluded, err = matchPattern(exclude, name); err != nil {
+				return "", err
+			}
+		}
+		if matched && !excluded {
+			byCommit[hash] = append(byCommit[hash], name)
+		}
+	}
+
+	head, err := b.resolve("HEAD")
+	if err != nil {
+		return "", err
+	}
+	commits, err := b.repo.Log(&gogit.LogOptions{From: head, Order: gogit.LogOrderCommitterTime})
+	if err != nil {
+		return "", err
+	}
+	var res string
+	err = commits.ForEach(func(c *object.Commit) error {
+		if names, ok := byCommit[c.Hash]; ok {
+			sort.Strings(names)
+			res = names[0]
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		return "", errors.New("no names found, cannot describe anything")
+	}
+	return res, nil
+}
+
+func (b *GoGitBackend) PointsAt(tagName string) ([]string, error) {
+	tags, err := b.tagCommits()
+	if err != nil {
+		return nil, err
+	}
+	target, ok := tags[tagName]
+	if !ok {
+		return nil, fmt.Errorf("the tag '%s' not found", tagName)
+	}
+	var res []string
+	for name, hash := range tags {
+		if hash == target {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func (b *GoGitBackend) Log(since, until, path string, excludes []string) ([]*Commit, error) {
+	if len(until) == 0 {
+		until = "HEAD"
+	}
+	head, err := b.resolve(until)
+	if err != nil {
+		return nil, err
+	}
+	var seen map[plumbing.Hash]bool
+	if len(since) > 0 {
+		from, err := b.resolve(since)
+		if err != nil {
+			return nil, err
+		}
+		if seen, err = b.reachable(from); err != nil {
+			return nil, err
+		}
+	}
+	commits, err := b.repo.Log(&gogit.LogOptions{From: head, Order: gogit.LogOrderCommitterTime})
+	if err != nil {
+		return nil, err
+	}
+	var res []*Commit
+	err = commits.ForEach(func(c *object.Commit) error {
+		if seen[c.Hash] || c.NumParents() > 1 {
+			return nil
+		}
+		if len(path) > 0 || len(excludes) > 0 {
+			ok, err := touches(c, path, excludes)
+			if err != nil || !ok {
+				return err
+			}
+		}
+		subject, body := splitMessage(c.Message)
+		hash := c.Hash.String()[:shortHashLength]
+		res = append(res, newCommit(hash, c.Author.Name, c.Author.Email, c.Author.When, subject, body))
+		return nil
+	})
+	return res, err
+}
+
+func (b *GoGitBackend) ReadTag(tagName string) (*Tag, error) {
+	ref, err := b.repo.Tag(tagName)
+	if err != nil {
+		return nil, fmt.Errorf("tag '%s' not found: %w", tagName, err)
+	}
+	tag, err := b.repo.TagObject(ref.Hash())
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		c, err := b.repo.CommitObject(ref.Hash())
+		if err != nil {
+			return nil, err
+		}
+		return &Tag{Name: tagName, Date: normalizeDate(c.Committer.When)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, body := splitMessage(tag.Message)
+	return &Tag{Name: tagName, Date: normalizeDate(tag.Tagger.When), Body: body}, nil
+}
+
+func (b *GoGitBackend) ShortHash(rev string) (string, error) {
+	hash, err := b.resolve(rev)
+	if err != nil {
+		return "", err
+	}
+	return hash.String()[:shortHashLength], nil
+}
+
+func (b *GoGitBackend) ReadFile(rev, path string) (string, error) {
+	hash, err := b.resolve(rev)
+	if err != nil {
+		return "", err
+	}
+	c, err := b.repo.CommitObject(hash)
+	if err != nil {
+		return "", err
+	}
+	f, err := c.File(path)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return "", fmt.Errorf("cannot read the file '%s' at '%s': %w", path, rev, fs.ErrNotExist)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot read the file '%s' at '%s': %w", path, rev, err)
+	}
+	return f.Contents()
+}
+
+func (b *GoGitBackend) FindFiles(name string) ([]string, error) {
+	index, err := b.repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, entry := range index.Entries {
+		if path.Base(entry.Name) == name {
+			res = append(res, entry.Name)
+		}
+	}
+	return res, nil
+}
+
+func (b *GoGitBackend) ChangedFiles() ([]string, error) {
+	w, err := b.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for name, s := range status {
+		if s.Worktree == gogit.Untracked || (s.Worktree == gogit.Unmodified && s.Staging == gogit.Unmodified) {
+			continue
+		}
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// Checkout writes the files of the revision to a temporary directory.
+func (b *GoGitBackend) Checkout(rev string) (string, func(), error) {
+	hash, err := b.resolve(rev)
+	if err != nil {
+		return "", nil, err
+	}
+	c, err := b.repo.CommitObject(hash)
+	if err != nil {
+		return "", nil, err
+	}
+	files, err := c.Files()
+	if err != nil {
+		return "", nil, err
+	}
+	tmp, err := os.MkdirTemp("", "bumptag")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanUp := func() {
+		_ = os.RemoveAll(tmp)
+	}
+	dir := filepath.Join(tmp, "worktree")
+	err = files.ForEach(func(f *object.File) error {
+		name := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return err
+		}
+		contents, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		if f.Mode == filemode.Symlink {
+			return os.Symlink(contents, name)
+		}
+		mode, err := f.Mode.ToOSFileMode()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(name, []byte(contents), mode)
+	})
+	if err != nil {
+		cleanUp()
+		return "", nil, err
+	}
+	return dir, cleanUp, nil
+}
+
+// Commit stages the files and commits them, the other staged files are kept out of the commit.
+func (b *GoGitBackend) Commit(message string, files []string) error {
+	if value, err := b.Config("commit.gpgsign"); err == nil {
+		if sign, _ := strconv.ParseBool(value); sign {
+			return errSigningNotSupported
+		}
+	}
+	w, err := b.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	restore, err := b.unstageOthers(w, files)
+	if err != nil {
+		return err
+	}
+	err = b.commitFiles(w, message, files)
+	if restoreErr := restore(); err == nil {
+		err = restoreErr
+	}
+	return err
+}
+
+func (b *GoGitBackend) commitFiles(w *gogit.Worktree, message string, files []string) error {
+	for _, name := range files {
+		if _, err := w.Add(name); err != nil {
+			return err
+		}
+	}
+	sig, err := b.signature()
+	if err != nil {
+		return err
+	}
+	_, err = w.Commit(message, &gogit.CommitOptions{Author: sig})
+	return err
+}
+
+// unstageOthers resets the index entries of the staged files not in the list to HEAD, because go-git commits
+// the whole index, unlike `git commit -- <files>`. It returns a function staging them back.
+func (b *GoGitBackend) unstageOthers(w *gogit.Worktree, files []string) (func() error, error) {
+	status, err := w.Status()
+	if err != nil {
+		return nil, err
+	}
+	idx, err := b.repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	staged := map[string]*index.Entry{}
+	for name, s := range status {
+		if s.Staging == gogit.Unmodified || s.Staging == gogit.Untracked || slices.Contains(files, name) {
+			continue
+		}
+		// a staged deletion has no entry
+		entry, _ := idx.Entry(name)
+		staged[name] = entry
+	}
+	if len(staged) == 0 {
+		return func() error { return nil }, nil
+	}
+	head, err := b.headTree()
+	if err != nil {
+		return nil, err
+	}
+	for name := range staged {
+		var headEntry *index.Entry
+		if entry, err := head.FindEntry(name); err == nil {
+			headEntry = &index.Entry{Name: name, Hash: entry.Hash, Mode: entry.Mode}
+		}
+		setIndexEntry(idx, name, headEntry)
+	}
+	if err := b.repo.Storer.SetIndex(idx); err != nil {
+		return nil, err
+	}
+	return func() error {
+		idx, err := b.repo.Storer.Index()
+		if err != nil {
+			return err
+		}
+		for name, entry := range staged {
+			setIndexEntry(idx, name, entry)
+		}
+		return b.repo.Storer.SetIndex(idx)
+	}, nil
+}
+
+func (b *GoGitBackend) headTree() (*object.Tree, error) {
+	head, err := b.resolve("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	commit, err := b.repo.CommitObject(head)
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
+
+// setIndexEntry replaces the entry of the path, nil removes it.
+func setIndexEntry(idx *index.Index, name string, entry *index.Entry) {
+	_, _ = idx.Remove(name)
+	if entry != nil {
+		copied := *entry
+		idx.Entries = append(idx.Entries, &copied)
+	}
+}
+
+func (b *GoGitBackend) CreateTag(tagName, annotation string, sign bool, _ string) error {
+	if sign {
+		return errSigningNotSupported
+	}
+	head, err := b.resolve("HEAD")
+	if err != nil {
+		return err
+	}
+	sig, err := b.signature()
+	if err != nil {
+		return err
+	}
+	_, err = b.repo.CreateTag(tagName, head, &gogit.CreateTagOptions{Tagger: sig, Message: annotation})
+	if err != nil {
+		return fmt.Errorf("cannot create the tag '%s': %w", tagName, err)
+	}
+	return nil
+}
+
+// VerifyTag cannot verify the signatures, the signed tags are not supported.
+func (b *GoGitBackend) VerifyTag(string) error {
+	return errSigningNotSupported
+}
+
+func (b *GoGitBackend) DeleteTag(tagName string) error {
+	if err := b.repo.DeleteTag(tagName); err != nil {
+		return fmt.Errorf("cannot delete the tag '%s': %w", tagName, err)
+	}
+	return nil
+}
+
+// Reset works like `git reset --merge`, it is close to `git reset --keep` used by the git command.
+func (b *GoGitBackend) Reset(rev string) error {
+	hash, err := b.resolve(rev)
+	if err != nil {
+		return err
+	}
+	w, err := b.repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return w.Reset(&gogit.ResetOptions{Commit: hash, Mode: gogit.MergeReset})
+}
+
+// ShowTag describes the tag and its commit like `git show` without the diff.
+func (b *GoGitBackend) ShowTag(tagName string) (string, error) {
+	ref, err := b.repo.Tag(tagName)
+	if err != nil {
+		return "", fmt.Errorf("the tag '%s' not found: %w", tagName, err)
+	}
+	var output []string
+	hash := ref.Hash()
+	if tag, err := b.repo.TagObject(hash); err == nil {
+		output = append(output,
+			"tag "+tag.Name,
+			"Tagger: "+tag.Tagger.String(),
+			"Date:   "+tag.Tagger.When.Format(gitDateFormat),
+			"",
+			strings.TrimRight(tag.Message, "\n"),
+			"",
+		)
+	}
+	if hash, err = b.peel(hash); err != nil {
+		return "", err
+	}
+	c, err := b.repo.CommitObject(hash)
+	if err != nil {
+		return "", err
+	}
+	output = append(output,
+		"commit "+c.Hash.String(),
+		"Author: "+c.Author.String(),
+		"Date:   "+c.Author.When.Format(gitDateFormat),
+		"",
+	)
+	for _, line := range strings.Split(strings.TrimRight(c.Message, "\n"), "\n") {
+		output = append(output, "    "+line)
+	}
+	return strings.Join(output, "\n"), nil
+}
+
+func (b *GoGitBackend) Remote() (string, error) {
+	head, err := b.repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return defaultRemote, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	cfg, err := b.repo.Config()
+	if err != nil {
+		return "", err
+	}
+	if head.Name().IsBranch() {
+		if branch, ok := cfg.Branches[head.Name().Short()]; ok && len(branch.Remote) > 0 {
+			return branch.Remote, nil
+		}
+	}
+	return "", fmt.Errorf("%w for the active branch '%s'", ErrNoRemote, head.Name().Short())
+}
+
+func (b *GoGitBackend) Branch() (string, error) {
+	head, err := b.repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return "", err
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		return "", nil
+	}
+	return head.Target().Short(), nil
+}
+
+func (b *GoGitBackend) Behind() (int, error) {
+	branchName, err := b.Branch()
+	if err != nil || len(branchName) == 0 {
+		return 0, err
+	}
+	cfg, err := b.repo.Config()
+	if err != nil {
+		return 0, err
+	}
+	branch, ok := cfg.Branches[branchName]
+	if !ok || len(branch.Remote) == 0 || len(branch.Merge) == 0 {
+		return 0, nil
+	}
+	if err := b.fetch(branch.Remote); err != nil {
+		return 0, err
+	}
+	upstream, err := b.repo.Reference(plumbing.NewRemoteReferenceName(branch.Remote, branch.Merge.Short()), true)
+	if err != nil {
+		return 0, err
+	}
+	head, err := b.resolve("HEAD")
+	if err != nil {
+		return 0, err
+	}
+	reachable, err := b.reachable(head)
+	if err != nil {
+		return 0, err
+	}
+	commits, err := b.repo.Log(&gogit.LogOptions{From: upstream.Hash()})
+	if err != nil {
+		return 0, err
+	}
+	var behind int
+	err = commits.ForEach(func(c *object.Commit) error {
+		if !reachable[c.Hash] {
+			behind++
+		}
+		return nil
+	})
+	return behind, err
+}
+
+// fetch updates the remote-tracking branches of the remote. The file transport of go-git runs git-upload-pack,
+// so a local remote is not fetched without the git binary and its remote-tracking branches are used as they are.
+func (b *GoGitBackend) fetch(name string) error {
+	r, err := b.repo.Remote(name)
+	if err != nil {
+		return err
+	}
+	if urls := r.Config().URLs; len(urls) > 0 {
+		ep, err := transport.NewEndpoint(urls[0])
+		if err != nil {
+			return err
+		}
+		if _, err := exec.LookPath("git"); err != nil && ep.Protocol == "file" {
+			return nil
+		}
+	}
+	err = r.Fetch(&gogit.FetchOptions{RemoteName: name})
+	if errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	return err
+}
+
+// remote returns the configured remote or an anonymous remote if the name is an URL or a path.
+func (b *GoGitBackend) remote(name string) (*gogit.Remote, error) {
+	r, err := b.repo.Remote(name)
+	if errors.Is(err, gogit.ErrRemoteNotFound) {
+		return gogit.NewRemote(b.repo.Storer, &config.RemoteConfig{Name: "anonymous", URLs: []string{name}}), nil
+	}
+	return r, err
+}
+
+func (b *GoGitBackend) HasRemoteTag(remote, tagName string) (bool, error) {
+	r, err := b.remote(remote)
+	if err != nil {
+		return false, err
+	}
+	refs, err := r.List(&gogit.ListOptions{})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	name := plumbing.NewTagReferenceName(tagName)
+	for _, ref := range refs {
+		if ref.Name() == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (b *GoGitBackend) PushTag(remote, tagName string) error {
+	r, err := b.remote(remote)
+	if err != nil {
+		return err
+	}
+	ref := plumbing.NewTagReferenceName(tagName)
+	err = r.Push(&gogit.PushOptions{
+		RemoteName: r.Config().Name,
+		RefSpecs:   []config.RefSpec{config.RefSpec(ref + ":" + ref)},
+	})
+	if errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,373 @@
+package bumptag
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobRegexp(t *testing.T) {
+	for pattern, names := range map[string][2][]string{
+		"v*":          {{"v1.0.0", "v"}, {"tools/v1.0.0", "1.0.0"}},
+		"*/*":         {{"tools/v1.0.0", "tools/foo/v1.0.0"}, {"v1.0.0"}},
+		"v1.3.0-rc.*": {{"v1.3.0-rc.1", "v1.3.0-rc.10"}, {"v1.3.0-rcX1", "v1.3.0"}},
+		"v?.0":        {{"v1.0", "v2.0"}, {"v10.0"}},
+		"[!v]*":       {{"deploy", "1.0.0"}, {"v1.0.0"}},
+		"[0-9]*":      {{"1.0.0"}, {"v1.0.0"}},
+		`\*`:          {{"*"}, {"v"}},
+		"v[":          {{"v["}, {"v"}},
+	} {
+		re, err := globRegexp(pattern)
+		assert.NoError(t, err)
+		for _, name := range names[0] {
+			assert.True(t, re.MatchString(name), "%s should match %s", pattern, name)
+		}
+		for _, name := range names[1] {
+			assert.False(t, re.MatchString(name), "%s should not match %s", pattern, name)
+		}
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	subject, body := splitMessage("feat: test\n")
+	assert.Equal(t, "feat: test", subject)
+	assert.Empty(t, body)
+
+	subject, body = splitMessage("feat: test\nsecond line\n\nbody\n\nBREAKING CHANGE: test\n")
+	assert.Equal(t, "feat: test second line", subject)
+	assert.Equal(t, "body\n\nBREAKING CHANGE: test", body)
+}
+
+func TestGoGitBackendRead(t *testing.T) {
+	prepareCommit, repo := prepareGit(t)
+	root, err := git("", "rev-parse", "--show-toplevel")
+	assert.NoError(t, err)
+	b, err := OpenGoGitBackend(root)
+	assert.NoError(t, err)
+	cli := repo.Backend
+
+	_, err = b.DescribeTag("", "")
+	assert.Error(t, err)
+
+	_, err = git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	_ = prepareModule(t, "tools/foo")
+	_, err = git("", "tag", "-a", "-m", "test", "-m", "* abc1234 test", "tools/foo/v0.1.0")
+	assert.NoError(t, err)
+	_, err = git("", "tag", "v1.1.0-rc.1")
+	assert.NoError(t, err)
+	_, err = git("", "checkout", "-b", "v2")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, err = git("", "tag", "v2.0.0")
+	assert.NoError(t, err)
+	_, err = git("", "checkout", "master")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, err = git("", "commit", "--allow-empty", "-m", "feat: test", "-m", "BREAKING CHANGE: test")
+	assert.NoError(t, err)
+
+	expectedRoot, err := cli.Root()
+	assert.NoError(t, err)
+	actualRoot, err := b.Root()
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRoot, actualRoot)
+
+	for _, name := range []string{"user.name", "branch.master.remote"} {
+		expected, err := cli.Config(name)
+		assert.NoError(t, err)
+		actual, err := b.Config(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+	_, err = b.Config("bumptag.unknown")
+	assert.EqualError(t, err, "the config option 'bumptag.unknown' is not set")
+
+	for _, pattern := range []string{"", "tools/foo/v*", "v1.*"} {
+		expected, err := cli.Tags(pattern)
+		assert.NoError(t, err)
+		actual, err := b.Tags(pattern)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+
+	expected, err := cli.MergedTags()
+	assert.NoError(t, err)
+	actual, err := b.MergedTags()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	assert.NotContains(t, actual, "v2.0.0")
+
+	for _, patterns := range [][2]string{{"", "*/*"}, {"tools/foo/v*", ""}, {"v1.0.*", ""}} {
+		expected, err := cli.DescribeTag(patterns[0], patterns[1])
+		assert.NoError(t, err)
+		actual, err := b.DescribeTag(patterns[0], patterns[1])
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+
+	expected, err = cli.PointsAt("tools/foo/v0.1.0")
+	assert.NoError(t, err)
+	actual, err = b.PointsAt("tools/foo/v0.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, []string{"tools/foo/v0.1.0", "v1.1.0-rc.1"}, actual)
+
+	for _, args := range []struct {
+		since, until, path string
+		excludes           []string
+	}{
+		{},
+		{since: "v1.0.0"},
+		{since: "tools/foo/v0.1.0"},
+		{path: "tools/foo"},
+		{excludes: []string{"tools/foo"}},
+		{until: "v2.0.0"},
+		{since: "v1.0.0", until: "v2.0.0"},
+	} {
+		expected, err := cli.Log(args.since, args.until, args.path, args.excludes)
+		assert.NoError(t, err)
+		actual, err := b.Log(args.since, args.until, args.path, args.excludes)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+
+	for _, name := range []string{"v1.0.0", "tools/foo/v0.1.0"} {
+		expected, err := cli.ReadTag(name)
+		assert.NoError(t, err)
+		actual, err := b.ReadTag(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+	tag, err := b.ReadTag("tools/foo/v0.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "* abc1234 test", tag.Body)
+	_, err = cli.ReadTag("v0.0.1")
+	assert.EqualError(t, err, "tag 'v0.0.1' not found")
+	_, err = b.ReadTag("v0.0.1")
+	assert.Error(t, err)
+
+	expectedHash, err := cli.ShortHash("v1.0.0")
+	assert.NoError(t, err)
+	actualHash, err := b.ShortHash("v1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedHash, actualHash)
+
+	expectedFile, err := cli.ReadFile("HEAD", "tools/foo/go.mod")
+	assert.NoError(t, err)
+	actualFile, err := b.ReadFile("HEAD", "tools/foo/go.mod")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedFile, strings.TrimSpace(actualFile))
+	for _, backend := range []Backend{cli, b} {
+		_, err = backend.ReadFile("v1.0.0", "tools/foo/go.mod")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		_, err = backend.ReadFile("test-rev", "tools/foo/go.mod")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, fs.ErrNotExist, "a bad revision is not a missing file")
+	}
+
+	expected, err = cli.FindFiles("go.mod")
+	assert.NoError(t, err)
+	actual, err = b.FindFiles("go.mod")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	expectedRemote, err := cli.Remote()
+	assert.NoError(t, err)
+	actualRemote, err := b.Remote()
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRemote, actualRemote)
+
+	dir, cleanUp, err := b.Checkout("tools/foo/v0.1.0")
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "tools", "foo", "go.mod"))
+	assert.NoError(t, err)
+	cleanUp()
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestGoGitBackendWrite(t *testing.T) {
+	_, _ = prepareGit(t)
+	root, err := git("", "rev-parse", "--show-toplevel")
+	assert.NoError(t, err)
+	b, err := OpenGoGitBackend(root)
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "test.txt"), []byte("test"), 0o600))
+	files, err := b.ChangedFiles()
+	assert.NoError(t, err)
+	assert.Empty(t, files, "the untracked files are ignored")
+	assert.NoError(t, b.Commit("Add test.txt", []string{"test.txt"}))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "test.txt"), []byte("changed"), 0o600))
+	files, err = b.ChangedFiles()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test.txt"}, files)
+	expected, err := NewCLIBackend(root).ChangedFiles()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, files)
+	_, err = git("", "checkout", "--", "test.txt")
+	assert.NoError(t, err)
+	output, err := git("", "log", "-1", "--pretty=%s %an")
+	assert.NoError(t, err)
+	assert.Equal(t, "Add test.txt Test Example", output)
+	output, err = git("", "status", "--porcelain")
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+
+	assert.NoError(t, b.CreateTag("v1.0.0", "Bump version v1.0.0\n\n* test", false, ""))
+	output, err = git("", "tag", "-n9", "v1.0.0")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "Bump version v1.0.0")
+	assert.Contains(t, output, "* test")
+	assert.Error(t, b.CreateTag("v1.0.0", "test", false, ""))
+	assert.Equal(t, errSigningNotSupported, b.CreateTag("v1.1.0", "test", true, ""))
+	assert.Equal(t, errSigningNotSupported, b.VerifyTag("v1.0.0"))
+
+	output, err = b.ShowTag("v1.0.0")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "tag v1.0.0\nTagger: Test Example <test@example.com>\n")
+	assert.Contains(t, output, "    Add test.txt")
+
+	assert.NoError(t, b.PushTag("origin", "v1.0.0"))
+	assert.NoError(t, b.PushTag("origin", "v1.0.0"))
+	output, err = git("", "ls-remote", "--tags", "origin")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "refs/tags/v1.0.0")
+	remoteDir, err := git("", "config", "--get", "remote.origin.url")
+	assert.NoError(t, err)
+	assert.NoError(t, b.CreateTag("v1.0.1", "test", false, ""))
+	assert.NoError(t, b.PushTag(remoteDir, "v1.0.1"), "the remote can be an URL or a path")
+	output, err = git("", "ls-remote", "--tags", "origin")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "refs/tags/v1.0.1")
+	assert.NoError(t, b.DeleteTag("v1.0.1"))
+
+	head, err := b.ShortHash("HEAD")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "reset.txt"), []byte("reset"), 0o600))
+	assert.NoError(t, b.Commit("Add reset.txt", []string{"reset.txt"}))
+	assert.NoError(t, b.Reset(head))
+	assert.NoFileExists(t, filepath.Join(root, "reset.txt"))
+	output, err = git("", "rev-parse", "--short", "HEAD")
+	assert.NoError(t, err)
+	assert.Equal(t, head, output)
+	output, err = git("", "status", "--porcelain")
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+
+	assert.NoError(t, b.DeleteTag("v1.0.0"))
+	output, err = git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+	assert.Error(t, b.DeleteTag("v1.0.0"))
+
+	_, err = git("", "config", "--local", "commit.gpgsign", "true")
+	assert.NoError(t, err)
+	assert.Equal(t, errSigningNotSupported, b.Commit("test", nil))
+
+	_, err = git("", "checkout", "--detach")
+	assert.NoError(t, err)
+	_, err = b.Remote()
+	assert.EqualError(t, err, "no remote for the active branch 'HEAD'")
+	assert.ErrorIs(t, err, ErrNoRemote)
+}
+
+func TestGoGitBackendRemote(t *testing.T) {
+	path := os.Getenv("PATH")
+	prepareCommit, repo := prepareGit(t)
+	root, err := git("", "rev-parse", "--show-toplevel")
+	assert.NoError(t, err)
+	b, err := OpenGoGitBackend(root)
+	assert.NoError(t, err)
+	cli := repo.Backend
+
+	for _, backend := range []Backend{cli, b} {
+		branch, err := backend.Branch()
+		assert.NoError(t, err)
+		assert.Equal(t, "master", branch)
+		behind, err := backend.Behind()
+		assert.NoError(t, err)
+		assert.Zero(t, behind)
+	}
+
+	prepareCommit()
+	_, err = git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	_, err = git("", "push", "origin", "master", "v1.0.0")
+	assert.NoError(t, err)
+	_, err = git("", "reset", "--hard", "HEAD~1")
+	assert.NoError(t, err)
+	remoteDir, err := git("", "config", "--get", "remote.origin.url")
+	assert.NoError(t, err)
+	for _, backend := range []Backend{cli, b} {
+		behind, err := backend.Behind()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, behind)
+		for _, remote := range []string{"origin", remoteDir} {
+			found, err := backend.HasRemoteTag(remote, "v1.0.0")
+			assert.NoError(t, err)
+			assert.True(t, found, remote)
+			found, err = backend.HasRemoteTag(remote, "v1.0")
+			assert.NoError(t, err)
+			assert.False(t, found, remote)
+		}
+	}
+	t.Setenv("PATH", "")
+	behind, err := b.Behind()
+	assert.NoError(t, err, "the local remote is not fetched without git")
+	assert.Equal(t, 1, behind, "the remote-tracking branch is compared")
+	t.Setenv("PATH", path)
+
+	_, err = git("", "checkout", "--detach")
+	assert.NoError(t, err)
+	for _, backend := range []Backend{cli, b} {
+		branch, err := backend.Branch()
+		assert.NoError(t, err)
+		assert.Empty(t, branch)
+		behind, err := backend.Behind()
+		assert.NoError(t, err)
+		assert.Zero(t, behind)
+	}
+}
+
+func TestBackendCommitStaged(t *testing.T) {
+	prepareCommit, repo := prepareGit(t)
+	root, err := git("", "rev-parse", "--show-toplevel")
+	assert.NoError(t, err)
+	b, err := OpenGoGitBackend(root)
+	assert.NoError(t, err)
+	for _, name := range []string{"modified.txt", "deleted.txt"} {
+		assert.NoError(t, os.WriteFile(name, []byte("test"), 0o600))
+	}
+	_, err = git("", "add", "modified.txt", "deleted.txt")
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "Add the test files")
+	assert.NoError(t, err)
+
+	for _, backend := range []Backend{repo.Backend, b} {
+		prepareCommit()
+		assert.NoError(t, os.WriteFile("VERSION", []byte("1.2.0\n"), 0o600))
+		assert.NoError(t, os.WriteFile("added.txt", []byte("added"), 0o600))
+		assert.NoError(t, os.WriteFile("modified.txt", []byte("changed"), 0o600))
+		_, err = git("", "add", "added.txt", "modified.txt")
+		assert.NoError(t, err)
+		_, err = git("", "rm", "--quiet", "deleted.txt")
+		assert.NoError(t, err)
+
+		assert.NoError(t, backend.Commit("Bump version", []string{"VERSION"}))
+		output, err := git("", "show", "--name-only", "--format=", "HEAD")
+		assert.NoError(t, err)
+		assert.Equal(t, "VERSION", output, "only the given files are committed")
+		output, err = git("", "diff", "--cached", "--no-renames", "--name-status")
+		assert.NoError(t, err)
+		assert.Equal(t, "A\tadded.txt\nD\tdeleted.txt\nM\tmodified.txt", output, "the other files are still staged")
+
+		_, err = git("", "reset", "--quiet", "--hard", "HEAD~1")
+		assert.NoError(t, err)
+	}
+}
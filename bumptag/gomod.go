@@ -0,0 +1,210 @@
+package bumptag
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	gomodule "golang.org/x/mod/module"
+)
+
+// expectedModulePath returns the module path from the go.mod at HEAD and the path expected by the major version,
+// see https://go.dev/ref/mod#major-version-suffixes.
+// Both paths are empty if the module has no go.mod.
+func (t *Tagger) expectedModulePath(r *Release) (string, string, error) {
+	data, err := t.Repo.Backend.ReadFile("HEAD", path.Join(r.Module.Path, "go.mod"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	current := modfile.ModulePath([]byte(data))
+	if len(current) == 0 || strings.HasPrefix(current, "gopkg.in/") {
+		return "", "", nil
+	}
+	prefix, pathMajor, ok := gomodule.SplitPathVersion(current)
+	if !ok {
+		return "", "", fmt.Errorf("invalid module path '%s'", current)
+	}
+	if gomodule.CheckPathMajor("v"+r.Version.String(), pathMajor) == nil {
+		return current, current, nil
+	}
+	expected := prefix
+	if r.Version.Major > 1 {
+		expected = fmt.Sprintf("%s/v%d", prefix, r.Version.Major)
+	}
+	return current, expected, nil
+}
+
+// guardModulePath refuses to tag a Go module if the major version does not match the module path,
+// or updates the module path and the imports in a new commit.
+func (t *Tagger) guardModulePath(r *Release) error {
+	current, expected, err := t.checkModulePath(r)
+	if err != nil || current == expected {
+		return err
+	}
+	return t.fixModulePath(r.Module, current, expected)
+}
+
+// CheckModulePath checks the major version of the release matches the module path of the Go module,
+// the mismatch is allowed with FixModulePath. Nothing is changed, so it is run by the dry runs instead of Tag.
+func (t *Tagger) CheckModulePath(r *Release) error {
+	_, _, err := t.checkModulePath(r)
+	return err
+}
+
+// checkModulePath returns the current and the expected module paths, the mismatch is an error without FixModulePath.
+func (t *Tagger) checkModulePath(r *Release) (string, string, error) {
+	current, expected, err := t.expectedModulePath(r)
+	if err != nil || current == expected || t.FixModulePath {
+		return current, expected, err
+	}
+	return "", "", fmt.Errorf(
+		"%w: the module path '%s' does not match the tag '%s', expected '%s', use --fix-module-path to update it",
+		ErrInvalidVersion,
+		current,
+		r.TagName,
+		expected,
+	)
+}
+
+func (t *Tagger) fixModulePath(m *Module, current, expected string) error {
+	if err := t.guardCleanTree(m.Path); err != nil {
+		return err
+	}
+	root, err := t.Repo.Backend.Root()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(root, filepath.FromSlash(m.Path))
+	files, err := replaceImports(dir, current, expected)
+	if err != nil {
+		return err
+	}
+	goMod := filepath.Join(dir, "go.mod")
+	if err := replaceModuleStmt(goMod, expected); err != nil {
+		return err
+	}
+	files = append(files, goMod)
+
+	var paths []string
+	for _, name := range files {
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	return t.commit(fmt.Sprintf("Update module path to %s", expected), paths)
+}
+
+func replaceModuleStmt(filename, modulePath string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	f, err := modfile.Parse(filename, data, nil)
+	if err != nil {
+		return err
+	}
+	if err := f.AddModuleStmt(modulePath); err != nil {
+		return err
+	}
+	data, err = f.Format()
+	if err != nil {
+		return err
+	}
+	return rewriteFile(filename, data)
+}
+
+// rewriteFile replaces the content of the existing file keeping its permissions.
+func rewriteFile(filename string, data []byte) error {
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, stat.Mode())
+}
+
+// replaceImports updates the imports of the module in all go files of the directory except the nested modules.
+func replaceImports(dir, current, expected string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name == dir {
+				return nil
+			}
+			if isIgnoredModulePath(d.Name()) {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(name, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") {
+			return nil
+		}
+		changed, err := replaceFileImports(name, current, expected)
+		if changed {
+			files = append(files, name)
+		}
+		return err
+	})
+	return files, err
+}
+
+func replaceFileImports(filename, current, expected string) (bool, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, data, parser.ImportsOnly)
+	if err != nil {
+		return false, err
+	}
+
+	type replacement struct {
+		start, end int
+		value      string
+	}
+	var replacements []replacement
+	for _, spec := range f.Imports {
+		value, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return false, err
+		}
+		if value != current && !strings.HasPrefix(value, current+"/") {
+			continue
+		}
+		replacements = append(replacements, replacement{
+			start: fset.Position(spec.Path.Pos()).Offset,
+			end:   fset.Position(spec.Path.End()).Offset,
+			value: strconv.Quote(expected + strings.TrimPrefix(value, current)),
+		})
+	}
+	if len(replacements) == 0 {
+		return false, nil
+	}
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start > replacements[j].start
+	})
+	for _, r := range replacements {
+		data = append(data[:r.start], append([]byte(r.value), data[r.end:]...)...)
+	}
+	return true, rewriteFile(filename, data)
+}
@@ -0,0 +1,189 @@
+package bumptag
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpectedModulePath(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	tagger := &Tagger{Repo: NewRepo(cli)}
+
+	r := &Release{
+		Module:          ModuleAt("tools/foo"),
+		PreviousVersion: *mustVersion("1.2.0"),
+		Version:         mustVersion("2.0.0"),
+	}
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:tools/foo/go.mod").
+		Return("", errors.New("test-error"))
+	ctrl.EXPECT().
+		Git("", "ls-tree", "--full-tree", "--name-only", "HEAD", "--", "tools/foo/go.mod").
+		Return("", nil)
+	current, expected, err := tagger.expectedModulePath(r)
+	assert.NoError(t, err, "the module has no go.mod")
+	assert.Empty(t, current)
+	assert.Empty(t, expected)
+
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:tools/foo/go.mod").
+		Return("", errors.New("test-error"))
+	ctrl.EXPECT().
+		Git("", "ls-tree", "--full-tree", "--name-only", "HEAD", "--", "tools/foo/go.mod").
+		Return("tools/foo/go.mod", nil)
+	_, _, err = tagger.expectedModulePath(r)
+	assert.EqualError(t, err, "test-error", "the failure to read go.mod is not ignored")
+
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:tools/foo/go.mod").
+		Return("module example.com/foo\n\ngo 1.17", nil)
+	current, expected, err = tagger.expectedModulePath(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/foo", current)
+	assert.Equal(t, "example.com/foo/v2", expected)
+
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:tools/foo/go.mod").
+		Return("module example.com/foo/v2", nil)
+	current, expected, err = tagger.expectedModulePath(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/foo/v2", current)
+	assert.Equal(t, "example.com/foo/v2", expected)
+
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:tools/foo/go.mod").
+		Return("module gopkg.in/foo.v1", nil)
+	current, expected, err = tagger.expectedModulePath(r)
+	assert.NoError(t, err)
+	assert.Empty(t, current)
+	assert.Empty(t, expected)
+
+	r.Module = RootModule()
+	r.PreviousVersion = *mustVersion("2.3.0")
+	r.Version = mustVersion("1.0.0")
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:go.mod").
+		Return("module example.com/foo/v2", nil)
+	current, expected, err = tagger.expectedModulePath(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com/foo/v2", current)
+	assert.Equal(t, "example.com/foo", expected)
+}
+
+func TestGuardModulePath(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	tagger := &Tagger{Repo: NewRepo(cli)}
+
+	r := &Release{
+		Module:          RootModule(),
+		PreviousVersion: *mustVersion("1.2.0"),
+		Version:         mustVersion("2.0.0"),
+		TagName:         "v2.0.0",
+	}
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:go.mod").
+		Return("module example.com/foo", nil)
+	err := tagger.guardModulePath(r)
+	assert.EqualError(
+		t,
+		err,
+		"invalid version: the module path 'example.com/foo' does not match the tag 'v2.0.0', "+
+			"expected 'example.com/foo/v2', "+
+			"use --fix-module-path to update it",
+	)
+
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:go.mod").
+		Return("module example.com/foo/v2", nil)
+	assert.NoError(t, tagger.guardModulePath(r))
+
+	r.PreviousVersion = *mustVersion("2.3.0")
+	r.Version = mustVersion("2.4.0")
+	r.TagName = "v2.4.0"
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:go.mod").
+		Return("module example.com/foo", nil)
+	assert.ErrorIs(t, tagger.guardModulePath(r), ErrInvalidVersion, "the module path is checked without a major bump")
+	r.PreviousVersion = *mustVersion("1.2.0")
+	r.Version = mustVersion("2.0.0")
+	r.TagName = "v2.0.0"
+
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:go.mod").
+		Return("module example.com/foo", nil).
+		Times(2)
+	assert.ErrorIs(t, tagger.CheckModulePath(r), ErrInvalidVersion)
+	tagger.FixModulePath = true
+	assert.NoError(t, tagger.CheckModulePath(r), "nothing is changed by the check")
+}
+
+func TestReplaceImports(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile := func(name, content string) string {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		assert.NoError(t, ioutil.WriteFile(name, []byte(content), 0o600))
+		return name
+	}
+	readFile := func(name string) string {
+		data, err := ioutil.ReadFile(name)
+		assert.NoError(t, err)
+		return string(data)
+	}
+
+	goMod := writeFile("go.mod", "module example.com/foo // test comment\n\ngo 1.17\n")
+	main := writeFile("main.go", `package main
+
+import (
+	"fmt"
+
+	"example.com/foo/bar"
+	baz "example.com/foo/baz"
+	"example.com/foobar"
+)
+
+func main() {
+	fmt.Println(bar.Bar, baz.Baz, foobar.FooBar, "example.com/foo/bar")
+}
+`)
+	bar := writeFile("bar/bar.go", "package bar\n\nimport \"example.com/foo\"\n")
+	baz := writeFile("baz/baz.go", "package baz\n")
+	nested := writeFile("nested/nested.go", "package nested\n\nimport \"example.com/foo/bar\"\n")
+	_ = writeFile("nested/go.mod", "module example.com/foo/nested\n")
+	testdata := writeFile("bar/testdata/test.go", "package test\n\nimport \"example.com/foo/bar\"\n")
+
+	files, err := replaceImports(dir, "example.com/foo", "example.com/foo/v2")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{main, bar}, files)
+	assert.Equal(t, `package main
+
+import (
+	"fmt"
+
+	"example.com/foo/v2/bar"
+	baz "example.com/foo/v2/baz"
+	"example.com/foobar"
+)
+
+func main() {
+	fmt.Println(bar.Bar, baz.Baz, foobar.FooBar, "example.com/foo/bar")
+}
+`, readFile(main))
+	assert.Equal(t, "package bar\n\nimport \"example.com/foo/v2\"\n", readFile(bar))
+	assert.Equal(t, "package baz\n", readFile(baz))
+	assert.Equal(t, "package nested\n\nimport \"example.com/foo/bar\"\n", readFile(nested))
+	assert.Equal(t, "package test\n\nimport \"example.com/foo/bar\"\n", readFile(testdata))
+
+	assert.NoError(t, replaceModuleStmt(goMod, "example.com/foo/v2"))
+	assert.Equal(t, "module example.com/foo/v2 // test comment\n\ngo 1.17\n", readFile(goMod))
+
+	_ = writeFile("broken.go", "package main\n\nimport (\n")
+	_, err = replaceImports(dir, "example.com/foo", "example.com/foo/v2")
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package bumptag
+
+import (
+	"sort"
+	"strings"
+)
+
+// releaseTag is a tag of a final release.
+type releaseTag struct {
+	name    string
+	version *Version
+}
+
+// releaseTags returns the tags of the final releases of the module in the ascending order of the versions,
+// the pre-releases and the tags which are not Semantic Versions are skipped.
+func releaseTags(m *Module, names []string) []releaseTag {
+	var res []releaseTag
+	for _, name := range names {
+		if len(name) == 0 || !strings.HasPrefix(name, m.Prefix) || (m.IsRoot() && strings.Contains(name, "/")) {
+			continue
+		}
+		v, err := ParseVersion(name, m.Prefix)
+		if err != nil || len(v.PreRelease) > 0 {
+			continue
+		}
+		res = append(res, releaseTag{name: name, version: v})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].version.LessThan(res[j].version)
+	})
+	return res
+}
+
+// lastReleaseTag returns the name of the highest tag of a final release of the module reachable from HEAD
+// and lower than the version, empty if there is none.
+func (r *Repo) lastReleaseTag(m *Module, v *Version) (string, error) {
+	names, err := r.Backend.MergedTags()
+	if err != nil {
+		return "", err
+	}
+	tags := releaseTags(m, names)
+	for i := len(tags) - 1; i >= 0; i-- {
+		if tags[i].version.LessThan(v) {
+			return tags[i].name, nil
+		}
+	}
+	return "", nil
+}
+
+// History returns the releases of the module for all tags of the final versions reachable from HEAD,
+// the latest release first. The change logs are read from the annotations of the tags if fromAnnotations is set
+// and the annotation is not empty, otherwise they are built from the commits between the consecutive tags,
+// so the commits of the pre-releases are included in the final release.
+func (b *Bumper) History(m *Module, fromAnnotations bool) ([]*Release, error) {
+	names, err := b.Repo.Backend.MergedTags()
+	if err != nil {
+		return nil, err
+	}
+	tags := releaseTags(m, names)
+	res := make([]*Release, len(tags))
+	var previous releaseTag
+	for i, t := range tags {
+		r := &Release{
+			Module:      m,
+			PreviousTag: previous.name,
+			Version:     t.version,
+			TagName:     t.name,
+		}
+		if previous.version != nil {
+			r.PreviousVersion = *previous.version
+		}
+		tag, err := b.Repo.Backend.ReadTag(t.name)
+		if err != nil {
+			return nil, err
+		}
+		r.Date = tag.Date
+		if r.Commits, err = b.Repo.Backend.Log(previous.name, t.name, m.Path, m.Excludes); err != nil {
+			return nil, err
+		}
+		if fromAnnotations && len(tag.Body) > 0 {
+			r.ChangeLog = tag.Body
+		} else if r.ChangeLog, err = b.Changelog.Build(r.Commits); err != nil {
+			return nil, err
+		}
+		res[len(tags)-1-i] = r
+		previous = t
+	}
+	return res, nil
+}
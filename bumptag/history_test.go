@@ -0,0 +1,69 @@
+package bumptag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReleaseTags(t *testing.T) {
+	names := []string{"v1.10.0", "", "v1.2.0", "deploy-prod", "v1.3.0-rc.1", "tools/foo/v0.1.0", "v1.9"}
+	var actual []string
+	for _, tag := range releaseTags(RootModule(), names) {
+		actual = append(actual, tag.name)
+	}
+	assert.Equal(t, []string{"v1.2.0", "v1.9", "v1.10.0"}, actual)
+
+	tags := releaseTags(ModuleAt("tools/foo"), names)
+	assert.Len(t, tags, 1)
+	assert.Equal(t, "tools/foo/v0.1.0", tags[0].name)
+}
+
+func TestBumperHistory(t *testing.T) {
+	prepareCommit, repo := prepareGit(t)
+	_, err := git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, err = git("", "tag", "v1.1.0-rc.1")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, err = git("", "tag", "-a", "-m", "Bump version v1.1.0", "-m", "* abc1234 test-annotation", "v1.1.0")
+	assert.NoError(t, err)
+	_ = prepareModule(t, "tools/foo")
+	_, err = git("", "tag", "tools/foo/v0.1.0")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, err = git("", "tag", "-a", "-m", "Bump version v1.2.0", "v1.2.0")
+	assert.NoError(t, err)
+	prepareCommit()
+
+	b := NewBumper(repo)
+	releases, err := b.History(RootModule(), false)
+	assert.NoError(t, err)
+	assert.Len(t, releases, 3)
+	assert.Equal(t, "v1.2.0", releases[0].TagName)
+	assert.Equal(t, "v1.1.0", releases[0].PreviousTag)
+	assert.Equal(t, "1.1.0", releases[0].PreviousVersion.String())
+	assert.Len(t, releases[0].Commits, 2)
+	assert.Contains(t, releases[0].ChangeLog, " commit-#3\n* ")
+	assert.False(t, releases[0].Date.IsZero())
+	assert.Equal(t, "v1.1.0", releases[1].TagName)
+	assert.Len(t, releases[1].Commits, 2)
+	assert.Contains(t, releases[1].ChangeLog, " commit-#2\n* ")
+	assert.Equal(t, "v1.0.0", releases[2].TagName)
+	assert.Empty(t, releases[2].PreviousTag)
+	assert.Len(t, releases[2].Commits, 1)
+
+	releases, err = b.History(RootModule(), true)
+	assert.NoError(t, err)
+	assert.Len(t, releases, 3)
+	assert.Contains(t, releases[0].ChangeLog, " commit-#3\n* ")
+	assert.Equal(t, "* abc1234 test-annotation", releases[1].ChangeLog)
+	assert.Contains(t, releases[2].ChangeLog, " commit-#0")
+
+	releases, err = b.History(ModuleAt("tools/foo"), false)
+	assert.NoError(t, err)
+	assert.Len(t, releases, 1)
+	assert.Contains(t, releases[0].ChangeLog, " Add module tools/foo")
+	assert.NotContains(t, releases[0].ChangeLog, "commit-#")
+}
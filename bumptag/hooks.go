@@ -0,0 +1,95 @@
+package bumptag
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+)
+
+// The names of the hooks, see Hooks.
+const (
+	HookPreBump  = "pre-bump"
+	HookPreTag   = "pre-tag"
+	HookPostTag  = "post-tag"
+	HookPostPush = "post-push"
+)
+
+// Hooks are the shell commands run by Tagger at the stages of the release in the root of the repository
+// with the BUMPTAG_NEW_TAG, BUMPTAG_PREV_TAG, BUMPTAG_VERSION and BUMPTAG_CHANGELOG_PATH environment variables.
+// The output of the commands is printed to stderr. A failing hook aborts the release, the changes are rolled back
+// by Tagger.Rollback until the tag is pushed.
+type Hooks struct {
+	// PreBump runs after the pre-flight checks before the version files are updated, e.g. to regenerate the code,
+	// the tracked files changed by the hook are committed together with the version files.
+	PreBump string
+	// PreTag runs right before the tag is created, e.g. to run the tests.
+	PreTag string
+	// PostTag runs after the tag is created.
+	PostTag string
+	// PostPush runs after the tag is pushed.
+	PostPush string
+}
+
+// runHook runs the shell command of the hook if it is set.
+func (t *Tagger) runHook(name, command string, r *Release) error {
+	if len(command) == 0 {
+		return nil
+	}
+	root, err := t.Repo.Backend.Root()
+	if err != nil {
+		return err
+	}
+	var changelogFile string
+	if len(t.ChangelogFile) > 0 {
+		if _, changelogFile, err = t.Repo.repoPath(t.ChangelogFile); err != nil {
+			return err
+		}
+	}
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), hookEnv(r, changelogFile)...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("the %s hook failed: %w", name, err)
+	}
+	return nil
+}
+
+// hookEnv returns the environment variables of the hooks, their names differ from the environment variables
+// of the settings, so bumptag run by a hook is not configured by them.
+func hookEnv(r *Release, changelogFile string) []string {
+	return []string{
+		"BUMPTAG_NEW_TAG=" + r.TagName,
+		"BUMPTAG_PREV_TAG=" + r.PreviousTag,
+		"BUMPTAG_VERSION=" + r.Version.String(),
+		"BUMPTAG_CHANGELOG_PATH=" + changelogFile,
+	}
+}
+
+// runPreBump runs the pre-bump hook and returns the tracked files changed by it,
+// the files changed before the hook are not returned.
+func (t *Tagger) runPreBump(r *Release) ([]string, error) {
+	if len(t.Hooks.PreBump) == 0 {
+		return nil, nil
+	}
+	before, err := t.Repo.Backend.ChangedFiles()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.runHook(HookPreBump, t.Hooks.PreBump, r); err != nil {
+		return nil, err
+	}
+	after, err := t.Repo.Backend.ChangedFiles()
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, name := range after {
+		if !slices.Contains(before, name) {
+			res = append(res, name)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,95 @@
+package bumptag
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHookEnv(t *testing.T) {
+	env := hookEnv(&Release{Version: mustVersion("1.2.0"), TagName: "v1.2.0"}, "CHANGELOG.md")
+	assert.Contains(t, env, "BUMPTAG_CHANGELOG_PATH=CHANGELOG.md")
+	for _, variable := range env {
+		name := strings.SplitN(variable, "=", 2)[0]
+		for _, s := range settings {
+			assert.NotEqual(t, s.env, name, "the hooks do not set the settings of the nested bumptag")
+		}
+	}
+}
+
+func TestTaggerHooks(t *testing.T) {
+	_, repo := prepareGit(t)
+	assert.NoError(t, os.WriteFile("generated.txt", []byte("0.0.0\n"), 0o600))
+	_, err := git("", "add", "generated.txt")
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "Add generated.txt")
+	assert.NoError(t, err)
+
+	tagger := &Tagger{
+		Repo:          repo,
+		ChangelogFile: "CHANGELOG.md",
+		SkipChecks:    []string{CheckBehind},
+		Hooks: Hooks{
+			PreBump:  `echo "$BUMPTAG_VERSION" > generated.txt`,
+			PreTag:   `echo "$BUMPTAG_PREV_TAG $BUMPTAG_NEW_TAG $BUMPTAG_CHANGELOG_PATH" > pre-tag.txt`,
+			PostTag:  `git tag --list > post-tag.txt`,
+			PostPush: `git ls-remote --tags origin > post-push.txt`,
+		},
+	}
+	r := &Release{
+		Module:      RootModule(),
+		PreviousTag: "v1.1.0",
+		Version:     mustVersion("1.2.0"),
+		TagName:     "v1.2.0",
+		Annotation:  "test",
+	}
+	assert.NoError(t, tagger.Tag(r))
+	output, err := git("", "log", "-2", "--stat", "--format=%s")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "Bump version to v1.2.0\n\n generated.txt")
+	data, err := os.ReadFile("generated.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.0\n", string(data))
+	data, err = os.ReadFile("pre-tag.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.1.0 v1.2.0 CHANGELOG.md\n", string(data))
+	data, err = os.ReadFile("post-tag.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.0\n", string(data))
+
+	_, err = tagger.Push(r)
+	assert.NoError(t, err)
+	data, err = os.ReadFile("post-push.txt")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "refs/tags/v1.2.0")
+	undone, err := tagger.Rollback()
+	assert.NoError(t, err)
+	assert.Empty(t, undone, "the pushed tag is not rolled back")
+
+	tagger.Hooks.PostPush = "exit 3"
+	r.Version = mustVersion("1.3.0")
+	r.TagName = "v1.3.0"
+	assert.NoError(t, tagger.Tag(r))
+	_, err = tagger.Push(r)
+	assert.EqualError(t, err, "the tag 'v1.3.0' has been pushed, but the post-push hook failed: exit status 3")
+
+	tagger.Hooks.PreTag = "exit 1"
+	r.Version = mustVersion("1.4.0")
+	r.TagName = "v1.4.0"
+	assert.EqualError(t, tagger.Tag(r), "the pre-tag hook failed: exit status 1")
+	output, err = git("", "tag", "--list", "v1.4.0")
+	assert.NoError(t, err)
+	assert.Empty(t, output, "a failing pre-tag hook aborts before the tag is created")
+	undone, err = tagger.Rollback()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"the commit 'Update CHANGELOG.md for v1.4.0'",
+		"the changes of 'CHANGELOG.md'",
+		"the commit 'Bump version to v1.4.0'",
+	}, undone)
+	data, err = os.ReadFile("generated.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0\n", string(data))
+}
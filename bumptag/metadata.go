@@ -0,0 +1,65 @@
+package bumptag
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	metadataPlaceholderRe = regexp.MustCompile(`{([a-z]+)(?::([^{}]*))?}`)
+	metadataRe            = regexp.MustCompile(`^[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*$`)
+
+	// buildNumberEnvs are the environment variables with a build number, in order of priority
+	buildNumberEnvs = []string{"BUMPTAG_BUILD", "GITHUB_RUN_NUMBER", "CI_PIPELINE_IID", "BUILD_NUMBER"}
+)
+
+func buildNumber() (string, error) {
+	for _, name := range buildNumberEnvs {
+		if value := os.Getenv(name); len(value) > 0 {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("build number not found, set one of the environment variables: %s", strings.Join(buildNumberEnvs, ", "))
+}
+
+func (r *Repo) expandPlaceholder(name, arg string, now time.Time) (string, error) {
+	switch name {
+	case "sha":
+		return r.Backend.ShortHash("HEAD")
+	case "date":
+		return now.UTC().Format("20060102"), nil
+	case "build":
+		return buildNumber()
+	case "env":
+		if value := os.Getenv(arg); len(value) > 0 {
+			return value, nil
+		}
+		return "", fmt.Errorf("environment variable '%s' is not set", arg)
+	}
+	return "", fmt.Errorf("unknown placeholder '%s'", name)
+}
+
+// ExpandMetadata replaces the placeholders {sha}, {date}, {build} and {env:NAME} in the build metadata,
+// the date is taken from now.
+func (r *Repo) ExpandMetadata(metadata string, now time.Time) (string, error) {
+	var err error
+	res := metadataPlaceholderRe.ReplaceAllStringFunc(metadata, func(placeholder string) string {
+		if err != nil {
+			return ""
+		}
+		parts := metadataPlaceholderRe.FindStringSubmatch(placeholder)
+		var value string
+		value, err = r.expandPlaceholder(parts[1], parts[2], now)
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	if !metadataRe.MatchString(res) {
+		return "", fmt.Errorf("invalid build metadata '%s'", res)
+	}
+	return res, nil
+}
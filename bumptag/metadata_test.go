@@ -0,0 +1,85 @@
+package bumptag
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockEnv(t testing.TB, name, value string) func() {
+	realValue, ok := os.LookupEnv(name)
+	assert.NoError(t, os.Setenv(name, value))
+	return func() {
+		if ok {
+			assert.NoError(t, os.Setenv(name, realValue))
+		} else {
+			assert.NoError(t, os.Unsetenv(name))
+		}
+	}
+}
+
+func TestBuildNumber(t *testing.T) {
+	var tearDowns []func()
+	for _, name := range buildNumberEnvs {
+		tearDowns = append(tearDowns, mockEnv(t, name, ""))
+	}
+	defer func() {
+		for _, tearDown := range tearDowns {
+			tearDown()
+		}
+	}()
+
+	_, err := buildNumber()
+	assert.Error(t, err)
+
+	tearDown := mockEnv(t, "BUILD_NUMBER", "42")
+	defer tearDown()
+	output, err := buildNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, "42", output)
+
+	tearDown = mockEnv(t, "BUMPTAG_BUILD", "451")
+	defer tearDown()
+	output, err = buildNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, "451", output)
+}
+
+func TestExpandMetadata(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	repo := NewRepo(cli)
+	now := time.Date(2020, 5, 17, 23, 0, 0, 0, time.UTC)
+	tearDownEnv := mockEnv(t, "BUMPTAG_BUILD", "451")
+	defer tearDownEnv()
+	tearDownEnv = mockEnv(t, "TEST_BUMPTAG_ENV", "test-value")
+	defer tearDownEnv()
+
+	ctrl.EXPECT().
+		Git("", "rev-parse", "--short", "HEAD").
+		Return("abc1234", nil)
+	output, err := repo.ExpandMetadata("build.{build}.{sha}", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "build.451.abc1234", output)
+
+	output, err = repo.ExpandMetadata("{date}.{env:TEST_BUMPTAG_ENV}", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "20200517.test-value", output)
+
+	ctrl.EXPECT().
+		Git("", "rev-parse", "--short", "HEAD").
+		Return("", errors.New("test-error"))
+	_, err = repo.ExpandMetadata("{sha}", now)
+	assert.EqualError(t, err, "test-error")
+
+	_, err = repo.ExpandMetadata("{env:TEST_BUMPTAG_UNKNOWN_ENV}", now)
+	assert.EqualError(t, err, "environment variable 'TEST_BUMPTAG_UNKNOWN_ENV' is not set")
+
+	_, err = repo.ExpandMetadata("{unknown}", now)
+	assert.EqualError(t, err, "unknown placeholder 'unknown'")
+
+	_, err = repo.ExpandMetadata("build..{build}", now)
+	assert.EqualError(t, err, "invalid build metadata 'build..451'")
+}
@@ -0,0 +1,125 @@
+package bumptag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TagPrefix is the prefix of the versions in the tag names.
+const TagPrefix = "v"
+
+// Module is a directory of the repository with its own tags prefixed by the path, e.g. `tools/foo/v1.2.0`.
+type Module struct {
+	// Path is relative to the root of the repository, empty for the root module
+	Path   string
+	Prefix string
+	// Excludes are the paths of the nested modules
+	Excludes []string
+}
+
+// NewModule returns the module of the directory relative to the root of the repository, empty or `.` for the root,
+// the tag prefix of a nested module is prefixed by its path, e.g. `tools/foo/v`.
+func NewModule(path, tagPrefix string) *Module {
+	if path == "." || len(path) == 0 {
+		return &Module{Prefix: tagPrefix}
+	}
+	return &Module{Path: path, Prefix: path + "/" + tagPrefix}
+}
+
+// RootModule returns the module of the whole repository with the default tag prefix.
+func RootModule() *Module {
+	return NewModule("", TagPrefix)
+}
+
+// ModuleAt returns the module of the directory relative to the root of the repository with the default tag prefix.
+func ModuleAt(path string) *Module {
+	return NewModule(path, TagPrefix)
+}
+
+// IsRoot checks if the module is the whole repository.
+func (m *Module) IsRoot() bool {
+	return len(m.Path) == 0
+}
+
+// TagName returns the name of the tag of the version.
+func (m *Module) TagName(v *Version) string {
+	return m.Prefix + v.String()
+}
+
+func (m *Module) String() string {
+	if m.IsRoot() {
+		return "."
+	}
+	return m.Path
+}
+
+func realPath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(path)
+}
+
+// Module returns the Go module in the directory.
+func (r *Repo) Module(dir string) (*Module, error) {
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+		return nil, fmt.Errorf("the directory '%s' is not a Go module: %w", dir, err)
+	}
+	root, err := r.Backend.Root()
+	if err != nil {
+		return nil, err
+	}
+	if root, err = realPath(root); err != nil {
+		return nil, err
+	}
+	path, err := realPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	path, err = filepath.Rel(root, path)
+	if err != nil {
+		return nil, err
+	}
+	path = filepath.ToSlash(path)
+	if path == ".." || strings.HasPrefix(path, "../") {
+		return nil, fmt.Errorf("the directory '%s' is outside of the repository '%s'", dir, root)
+	}
+	return NewModule(path, r.TagPrefix), nil
+}
+
+func isIgnoredModulePath(path string) bool {
+	for _, name := range strings.Split(path, "/") {
+		if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+// Modules returns all Go modules of the repository,
+// the commits of the nested modules are excluded from the parent modules.
+func (r *Repo) Modules() ([]*Module, error) {
+	names, err := r.Backend.FindFiles("go.mod")
+	if err != nil {
+		return nil, err
+	}
+	var modules []*Module
+	for _, name := range names {
+		path := filepath.ToSlash(filepath.Dir(name))
+		if path != "." && isIgnoredModulePath(path) {
+			continue
+		}
+		modules = append(modules, NewModule(path, r.TagPrefix))
+	}
+	for _, m := range modules {
+		for _, nested := range modules {
+			if nested != m && !nested.IsRoot() && (m.IsRoot() || strings.HasPrefix(nested.Path, m.Path+"/")) {
+				m.Excludes = append(m.Excludes, nested.Path)
+			}
+		}
+	}
+	return modules, nil
+}
@@ -0,0 +1,109 @@
+package bumptag
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModule(t *testing.T) {
+	assert.True(t, RootModule().IsRoot())
+	assert.Equal(t, ".", RootModule().String())
+	assert.Equal(t, RootModule(), ModuleAt("."))
+
+	m := ModuleAt("tools/foo")
+	assert.Equal(t, &Module{Path: "tools/foo", Prefix: "tools/foo/v"}, m)
+	assert.False(t, m.IsRoot())
+	assert.Equal(t, "tools/foo", m.String())
+	assert.Equal(t, "tools/foo/v1.2.0", m.TagName(&Version{Version: *semver.New("1.2.0")}))
+
+	assert.Equal(t, &Module{Prefix: "release-"}, NewModule("", "release-"))
+	assert.Equal(t, &Module{Path: "tools/foo", Prefix: "tools/foo/"}, NewModule("tools/foo", ""))
+}
+
+func TestIsIgnoredModulePath(t *testing.T) {
+	assert.False(t, isIgnoredModulePath("tools/foo"))
+	assert.True(t, isIgnoredModulePath("vendor/example.com/foo"))
+	assert.True(t, isIgnoredModulePath("foo/testdata/bar"))
+	assert.True(t, isIgnoredModulePath(".github/tools"))
+	assert.True(t, isIgnoredModulePath("_examples"))
+}
+
+func TestModules(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	repo := NewRepo(cli)
+
+	ctrl.EXPECT().
+		Git("", "ls-files", "--full-name", "--", ":(top,glob)**/go.mod").
+		Return("", errors.New("test-error"))
+	_, err := repo.Modules()
+	assert.EqualError(t, err, "test-error")
+
+	ctrl.EXPECT().
+		Git("", "ls-files", "--full-name", "--", ":(top,glob)**/go.mod").
+		Return("go.mod\ntools/foo/go.mod\ntools/foo/bar/go.mod\ntools/foo/testdata/go.mod\nbaz/go.mod", nil)
+	modules, err := repo.Modules()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Module{
+		{Prefix: "v", Excludes: []string{"tools/foo", "tools/foo/bar", "baz"}},
+		{Path: "tools/foo", Prefix: "tools/foo/v", Excludes: []string{"tools/foo/bar"}},
+		{Path: "tools/foo/bar", Prefix: "tools/foo/bar/v"},
+		{Path: "baz", Prefix: "baz/v"},
+	}, modules)
+
+	repo.TagPrefix = "release-"
+	ctrl.EXPECT().
+		Git("", "ls-files", "--full-name", "--", ":(top,glob)**/go.mod").
+		Return("go.mod\ntools/foo/go.mod", nil)
+	modules, err = repo.Modules()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Module{
+		{Prefix: "release-", Excludes: []string{"tools/foo"}},
+		{Path: "tools/foo", Prefix: "tools/foo/release-"},
+	}, modules)
+	assert.Equal(t, &Module{Prefix: "release-"}, repo.RootModule())
+}
+
+func prepareModule(t testing.TB, path string) string {
+	root, err := git("", "rev-parse", "--show-toplevel")
+	assert.NoError(t, err)
+	dir := filepath.Join(root, filepath.FromSlash(path))
+	assert.NoError(t, os.MkdirAll(dir, 0o755))
+	content := "module " + filepath.ToSlash(filepath.Join("example.com/test", path)) + "\n\ngo 1.17\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(content), 0o600))
+	_, err = git("", "add", filepath.Join(path, "go.mod"))
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "Add module "+path)
+	assert.NoError(t, err)
+	return dir
+}
+
+func TestRepoModule(t *testing.T) {
+	_, repo := prepareGit(t)
+
+	root, err := git("", "rev-parse", "--show-toplevel")
+	assert.NoError(t, err)
+	_, err = repo.Module(root)
+	assert.Error(t, err)
+
+	dir := prepareModule(t, "tools/foo")
+	m, err := repo.Module(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, ModuleAt("tools/foo"), m)
+
+	_ = prepareModule(t, ".")
+	m, err = repo.Module(root)
+	assert.NoError(t, err)
+	assert.Equal(t, RootModule(), m)
+
+	outside := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(outside, "go.mod"), []byte("module test\n"), 0o600))
+	_, err = repo.Module(outside)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is outside of the repository")
+}
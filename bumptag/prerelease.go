@@ -0,0 +1,77 @@
+package bumptag
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+)
+
+var preReleaseChannelRe = regexp.MustCompile(`^[0-9A-Za-z-]*[A-Za-z-][0-9A-Za-z-]*$`)
+
+// ValidatePreReleaseChannel returns an error if the channel cannot be used in the pre-releases, e.g. `rc`.
+func ValidatePreReleaseChannel(channel string) error {
+	if !preReleaseChannelRe.MatchString(channel) {
+		return fmt.Errorf("invalid pre-release channel '%s'", channel)
+	}
+	return nil
+}
+
+// parsePreRelease splits a pre-release like `rc.2` into the channel and the number.
+func parsePreRelease(preRelease semver.PreRelease) (string, int64, bool) {
+	parts := strings.Split(string(preRelease), ".")
+	if len(parts) != 2 {
+		return "", 0, false
+	}
+	number, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return parts[0], number, true
+}
+
+// lastPreReleaseNumber returns the highest number of the existing pre-releases of the version for the channel.
+func (r *Repo) lastPreReleaseNumber(m *Module, v *Version, channel string) (int64, error) {
+	pattern := fmt.Sprintf("%s%d.%d.%d-%s.*", m.Prefix, v.Major, v.Minor, v.Patch, channel)
+	names, err := r.Backend.Tags(pattern)
+	if err != nil {
+		return 0, err
+	}
+	var last int64
+	for _, name := range names {
+		if len(name) == 0 {
+			continue
+		}
+		tag, err := NewVersion(strings.TrimPrefix(name, m.Prefix))
+		if err != nil {
+			continue
+		}
+		c, number, ok := parsePreRelease(tag.PreRelease)
+		if ok && c == channel && number > last {
+			last = number
+		}
+	}
+	return last, nil
+}
+
+// setPreRelease turns the version into the next pre-release of the channel, e.g. v1.2.0 -> v1.3.0-rc.1 -> v1.3.0-rc.2.
+// The version of a pre-release tag is not incremented unless the bump level is explicitly requested.
+func (r *Repo) setPreRelease(m *Module, v *Version, channel string, level BumpLevel, explicitLevel bool) error {
+	if err := ValidatePreReleaseChannel(channel); err != nil {
+		return err
+	}
+	if len(v.PreRelease) == 0 || explicitLevel {
+		v.increment(level)
+	}
+	v.PreRelease = ""
+	v.Metadata = ""
+
+	last, err := r.lastPreReleaseNumber(m, v, channel)
+	if err != nil {
+		return err
+	}
+	v.PreRelease = semver.PreRelease(fmt.Sprintf("%s.%d", channel, last+1))
+	return nil
+}
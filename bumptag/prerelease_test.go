@@ -0,0 +1,69 @@
+package bumptag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePreRelease(t *testing.T) {
+	channel, number, ok := parsePreRelease("rc.2")
+	assert.True(t, ok)
+	assert.Equal(t, "rc", channel)
+	assert.Equal(t, int64(2), number)
+
+	_, _, ok = parsePreRelease("rc")
+	assert.False(t, ok)
+	_, _, ok = parsePreRelease("rc.x")
+	assert.False(t, ok)
+	_, _, ok = parsePreRelease("")
+	assert.False(t, ok)
+}
+
+func TestSetPreRelease(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	repo := NewRepo(cli)
+
+	tag := mustVersion("1.2.0")
+	ctrl.EXPECT().
+		Git("", "tag", "--list", "v1.3.0-rc.*").
+		Return("", nil)
+	err := repo.setPreRelease(RootModule(), tag, "rc", BumpMinor, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0-rc.1", tag.String())
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list", "v1.3.0-rc.*").
+		Return("v1.3.0-rc.1\nv1.3.0-rc.x\nv1.3.0-rc.3+build.5", nil)
+	err = repo.setPreRelease(RootModule(), tag, "rc", BumpMinor, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0-rc.4", tag.String())
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list", "v1.3.0-beta.*").
+		Return("", nil)
+	err = repo.setPreRelease(RootModule(), tag, "beta", BumpMinor, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.3.0-beta.1", tag.String())
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list", "v2.0.0-beta.*").
+		Return("", nil)
+	err = repo.setPreRelease(RootModule(), tag, "beta", BumpMajor, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "2.0.0-beta.1", tag.String())
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list", "v2.0.0-beta.*").
+		Return("", errors.New("test-error"))
+	err = repo.setPreRelease(RootModule(), tag, "beta", BumpMinor, false)
+	assert.EqualError(t, err, "test-error")
+
+	err = repo.setPreRelease(RootModule(), tag, "rc.1", BumpMinor, false)
+	assert.EqualError(t, err, "invalid pre-release channel 'rc.1'")
+	assert.NoError(t, ValidatePreReleaseChannel("rc"))
+	assert.EqualError(t, ValidatePreReleaseChannel("rc.1"), "invalid pre-release channel 'rc.1'")
+	err = repo.setPreRelease(RootModule(), tag, "42", BumpMinor, false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package bumptag
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// Replacement is a rule writing the new version into the files by a regular expression,
+// e.g. the Dockerfiles, the install snippets of README.md, the Helm values or the Kustomize images.
+type Replacement struct {
+	// File is the glob pattern of the files, the path is relative to the root of the repository.
+	File string
+	// Pattern matches the text to replace, all matches are replaced.
+	Pattern *regexp.Regexp
+	// Template renders the replacement with the Release as the data, e.g. `{{.TagName}}`,
+	// the rendered text can refer to the submatches of the pattern as `$1` or `${name}`.
+	Template *template.Template
+}
+
+// NewReplacement parses the pattern and the template of the replacement,
+// e.g. `NewReplacement("Dockerfile", "(ARG VERSION=)\\S+", "${1}{{.Version}}")`.
+func NewReplacement(file, pattern, replacement string) (*Replacement, error) {
+	if len(file) == 0 {
+		return nil, fmt.Errorf("the file of the replacement '%s' is not set", pattern)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern of the replacement in '%s': %w", file, err)
+	}
+	tmpl, err := template.New("replacement").Funcs(TemplateFuncs).Option("missingkey=error").Parse(replacement)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template of the replacement in '%s': %w", file, err)
+	}
+	return &Replacement{File: file, Pattern: re, Template: tmpl}, nil
+}
+
+// Apply replaces all matches of the pattern in the content of the file with the rendered template,
+// the pattern matching nothing is an error, so the outdated rules are noticed.
+func (rp *Replacement) Apply(data []byte, r *Release) ([]byte, error) {
+	if !rp.Pattern.Match(data) {
+		return nil, fmt.Errorf("the pattern '%s' matches nothing", rp.Pattern)
+	}
+	var replacement strings.Builder
+	if err := rp.Template.Execute(&replacement, r); err != nil {
+		return nil, err
+	}
+	return rp.Pattern.ReplaceAll(data, []byte(replacement.String())), nil
+}
+
+// files returns the absolute paths of the files of the rule, the pattern matching no files is an error.
+func (rp *Replacement) files(root string) ([]string, error) {
+	pattern := rp.File
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(root, filepath.FromSlash(pattern))
+	}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("the replacement file pattern '%s' matches no files", rp.File)
+	}
+	return files, nil
+}
@@ -0,0 +1,44 @@
+package bumptag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReplacement(t *testing.T) {
+	_, err := NewReplacement("", `\d+`, "{{.Version}}")
+	assert.EqualError(t, err, "the file of the replacement '\\d+' is not set")
+	_, err = NewReplacement("Dockerfile", `(`, "{{.Version}}")
+	assert.EqualError(
+		t,
+		err,
+		"invalid pattern of the replacement in 'Dockerfile': error parsing regexp: missing closing ): `(`",
+	)
+	_, err = NewReplacement("Dockerfile", `\d+`, "{{.Version")
+	assert.Error(t, err)
+}
+
+func TestReplacementApply(t *testing.T) {
+	r := &Release{Version: mustVersion("1.2.0"), TagName: "v1.2.0"}
+
+	rp, err := NewReplacement("Dockerfile", `(ARG VERSION=)\S+`, "${1}{{.Version}}")
+	assert.NoError(t, err)
+	data, err := rp.Apply([]byte("FROM alpine\nARG VERSION=1.1.0\n"), r)
+	assert.NoError(t, err)
+	assert.Equal(t, "FROM alpine\nARG VERSION=1.2.0\n", string(data))
+
+	rp, err = NewReplacement("README.md", `(?P<cmd>go install example\.com/app@)v[\d.]+`, "${cmd}{{.TagName}}")
+	assert.NoError(t, err)
+	data, err = rp.Apply([]byte("go install example.com/app@v1.1.0\n\n    go install example.com/app@v1.0.0\n"), r)
+	assert.NoError(t, err)
+	assert.Equal(t, "go install example.com/app@v1.2.0\n\n    go install example.com/app@v1.2.0\n", string(data))
+
+	_, err = rp.Apply([]byte("go get example.com/app\n"), r)
+	assert.EqualError(t, err, "the pattern '(?P<cmd>go install example\\.com/app@)v[\\d.]+' matches nothing")
+
+	rp, err = NewReplacement("README.md", `v[\d.]+`, "{{.Unknown}}")
+	assert.NoError(t, err)
+	_, err = rp.Apply([]byte("v1.1.0"), r)
+	assert.Error(t, err)
+}
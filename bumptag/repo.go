@@ -0,0 +1,196 @@
+package bumptag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const defaultRemote = "origin"
+
+// The strategies to find the latest tag.
+const (
+	// StrategyDescribe finds the nearest tag reachable from HEAD
+	StrategyDescribe = "describe"
+	// StrategyHighest finds the highest version of all tags
+	StrategyHighest = "highest"
+	// StrategyHighestReachable finds the highest version of the tags reachable from HEAD
+	StrategyHighestReachable = "highest-reachable"
+)
+
+// ValidateStrategy returns an error if the name is not a strategy to find the latest tag.
+func ValidateStrategy(name string) error {
+	switch name {
+	case StrategyDescribe, StrategyHighest, StrategyHighestReachable:
+		return nil
+	}
+	return fmt.Errorf("unknown strategy '%s'", name)
+}
+
+// Repo is a git repository.
+type Repo struct {
+	Backend Backend
+	// TagPrefix is the prefix of the versions in the tag names, e.g. `v` for `v1.2.0`.
+	TagPrefix string
+	// Warn is called with the warnings, e.g. the skipped tags, the warnings are dropped if nil.
+	Warn func(message string)
+}
+
+// NewRepo returns the repository accessed by the backend with the default tag prefix.
+func NewRepo(backend Backend) *Repo {
+	return &Repo{Backend: backend, TagPrefix: TagPrefix}
+}
+
+// RootModule returns the module of the whole repository.
+func (r *Repo) RootModule() *Module {
+	return NewModule("", r.TagPrefix)
+}
+
+// OpenRepo opens the repository containing the directory by the backend with the given name, see OpenBackend.
+func OpenRepo(dir, backend string) (*Repo, error) {
+	b, err := OpenBackend(backend, dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewRepo(b), nil
+}
+
+// Config returns the value of the git config option or the default value if it is not set.
+func (r *Repo) Config(name, defaultValue string) string {
+	output, err := r.Backend.Config(name)
+	if err != nil {
+		return defaultValue
+	}
+	return output
+}
+
+// ConfigBool returns the boolean value of the git config option or the default value if it is not set or invalid.
+func (r *Repo) ConfigBool(name string, defaultValue bool) bool {
+	output := r.Config(name, strconv.FormatBool(defaultValue))
+	value, err := strconv.ParseBool(output)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// latestTagAt returns the highest version of the tags pointing at the same commit as the given tag,
+// so the final release wins over its pre-releases.
+func (r *Repo) latestTagAt(m *Module, tag *Version, tagName string) (*Version, string, error) {
+	names, err := r.Backend.PointsAt(tagName)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, name := range names {
+		if name == tagName || !strings.HasPrefix(name, m.Prefix) {
+			continue
+		}
+		if v, err := ParseVersion(name, m.Prefix); err == nil && tag.LessThan(v) {
+			tag, tagName = v, name
+		}
+	}
+	return tag, tagName, nil
+}
+
+// hasTags checks if the module has any tags, the tags of the nested modules are ignored by the root module.
+func (r *Repo) hasTags(m *Module) (bool, error) {
+	var pattern string
+	if !m.IsRoot() {
+		pattern = m.Prefix + "*"
+	}
+	names, err := r.Backend.Tags(pattern)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range names {
+		if len(name) > 0 && (!m.IsRoot() || !strings.Contains(name, "/")) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *Repo) describeTag(m *Module) (*Version, string, error) {
+	found, err := r.hasTags(m)
+	if err != nil {
+		return nil, "", err
+	}
+	if !found {
+		return &Version{}, "", nil
+	}
+	var match, exclude string
+	if m.IsRoot() {
+		exclude = "*/*"
+	} else {
+		match = m.Prefix + "*"
+	}
+	currentTagName, err := r.Backend.DescribeTag(match, exclude)
+	if err != nil {
+		return nil, "", err
+	}
+	if m.IsRoot() && !strings.HasPrefix(currentTagName, m.Prefix) {
+		m.Prefix = ""
+	}
+
+	tag, err := ParseVersion(currentTagName, m.Prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	return r.latestTagAt(m, tag, currentTagName)
+}
+
+// warn reports the warning by Warn if it is set.
+func (r *Repo) warn(format string, args ...interface{}) {
+	if r.Warn != nil {
+		r.Warn(fmt.Sprintf(format, args...))
+	}
+}
+
+// highestTag returns the highest version of the given tags,
+// the tags without the prefix are ignored and the non-semver tags are skipped with a warning.
+func (r *Repo) highestTag(m *Module, names []string) (*Version, string) {
+	tag := &Version{}
+	var tagName string
+	for _, name := range names {
+		if len(name) == 0 || !strings.HasPrefix(name, m.Prefix) {
+			continue
+		}
+		v, err := ParseVersion(name, m.Prefix)
+		if err != nil {
+			r.warn("skipping the tag '%s': %s", name, err)
+			continue
+		}
+		if len(tagName) == 0 || tag.LessThan(v) {
+			tag, tagName = v, name
+		}
+	}
+	return tag, tagName
+}
+
+// FindTag returns the version and the name of the latest tag of the module,
+// the version is 0.0.0 and the name is empty if the module has no tags.
+// The prefix of the root module is cleared if its tags have no prefix, e.g. `1.2.3`.
+func (r *Repo) FindTag(m *Module, strategy string) (*Version, string, error) {
+	var names []string
+	var err error
+	switch strategy {
+	case StrategyDescribe:
+		return r.describeTag(m)
+	case StrategyHighest:
+		names, err = r.Backend.Tags("")
+	case StrategyHighestReachable:
+		names, err = r.Backend.MergedTags()
+	default:
+		return nil, "", fmt.Errorf("unknown strategy '%s'", strategy)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	tag, tagName := r.highestTag(m, names)
+	return tag, tagName, nil
+}
+
+// Commits returns the commits of the module since the given tag, or all commits if the tag is empty.
+func (r *Repo) Commits(since string, m *Module) ([]*Commit, error) {
+	return r.Backend.Log(since, "", m.Path, m.Excludes)
+}
@@ -0,0 +1,371 @@
+package bumptag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockGit is a mock of MockGit function
+type MockGit struct {
+	ctrl     *gomock.Controller
+	recorder *MockGitMockRecorder
+}
+
+// MockGitMockRecorder is the mock recorder for MockGit
+type MockGitMockRecorder struct {
+	mock *MockGit
+}
+
+// NewMockGit creates a new mock instance
+func NewMockGit(ctrl *gomock.Controller) *MockGit {
+	mock := &MockGit{ctrl: ctrl}
+	mock.recorder = &MockGitMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockGit) EXPECT() *MockGitMockRecorder {
+	return m.recorder
+}
+
+// Git mocks base method
+func (m *MockGit) Git(arg0 string, arg1 ...string) (string, error) {
+	m.ctrl.T.Helper()
+	msg := fmt.Sprintf("git %s", strings.Join(arg1, " "))
+	if len(arg0) > 0 {
+		msg += "\nInput: " + arg0
+	}
+	m.ctrl.T.(testing.TB).Log(msg)
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Git", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Git indicates an expected call of Git
+func (mr *MockGitMockRecorder) Git(arg0 string, arg1 ...string) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Git", reflect.TypeOf((*MockGit)(nil).Git), varargs...)
+}
+
+// mockGit returns the cli backend running the mocked git.
+func mockGit(t testing.TB) (*MockGit, *CLIBackend) {
+	mockGit := NewMockGit(gomock.NewController(t))
+	return mockGit, &CLIBackend{git: mockGit.Git}
+}
+
+// git runs the git binary in the current directory.
+func git(input string, arg ...string) (string, error) {
+	return runGit("")(input, arg...)
+}
+
+// prepareGit creates a repository with one commit pushed to the remote and changes the current directory to it.
+func prepareGit(t *testing.T) (prepareCommit func(), repo *Repo) {
+	dir := t.TempDir()
+	t.Logf("Dir: %s", dir)
+	remoteDir := t.TempDir()
+	t.Logf("Remote dir: %s", remoteDir)
+
+	cmd := exec.Command("git", "init", "--bare")
+	cmd.Dir = remoteDir
+	assert.NoError(t, cmd.Run())
+
+	t.Chdir(dir)
+	for _, args := range [][]string{
+		{"init"},
+		{"remote", "add", "origin", remoteDir},
+		{"config", "--local", "commit.gpgsign", "false"},
+		{"config", "--local", "tag.gpgSign", "false"},
+		{"config", "--local", "user.email", "test@example.com"},
+		{"config", "--local", "user.name", "Test Example"},
+	} {
+		_, err := git("", args...)
+		assert.NoError(t, err)
+	}
+
+	var commitNumber int
+	prepareCommit = func() {
+		msg := fmt.Sprintf("commit-#%d", commitNumber)
+		commitNumber++
+		f, err := os.CreateTemp(dir, msg+"-*.txt")
+		assert.NoError(t, err)
+		_, err = f.WriteString(msg)
+		assert.NoError(t, err)
+		assert.NoError(t, f.Close())
+		_, err = git("", "add", filepath.Base(f.Name()))
+		assert.NoError(t, err)
+		_, err = git("", "commit", "-m", msg)
+		assert.NoError(t, err)
+	}
+
+	prepareCommit()
+	_, err := git("", "push", "--set-upstream", "origin", "master")
+	assert.NoError(t, err)
+	return prepareCommit, NewRepo(NewCLIBackend(""))
+}
+
+func TestMockedGit(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	ctrl.EXPECT().
+		Git("").Return("test output", nil)
+	output, err := cli.git("")
+	assert.NoError(t, err)
+	assert.Equal(t, "test output", output)
+}
+
+func TestOpenRepo(t *testing.T) {
+	repo, err := OpenRepo(".", BackendCLI)
+	assert.NoError(t, err)
+	assert.IsType(t, &CLIBackend{}, repo.Backend)
+
+	_, err = OpenRepo(".", "test-backend")
+	assert.EqualError(t, err, "unknown backend 'test-backend'")
+}
+
+func TestRepoConfig(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	repo := NewRepo(cli)
+
+	ctrl.EXPECT().
+		Git("", "config", "--get", "test-name").
+		Return("test-value", nil)
+	output := repo.Config("test-name", "test-default-value")
+	assert.Equal(t, "test-value", output)
+
+	ctrl.EXPECT().
+		Git("", "config", "--get", "test-name").
+		Return("", errors.New("test-random-error"))
+	output = repo.Config("test-name", "test-default-value")
+	assert.Equal(t, "test-default-value", output)
+}
+
+func TestRepoConfigBool(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	repo := NewRepo(cli)
+
+	ctrl.EXPECT().
+		Git("", "config", "--get", "test-name").
+		Return("false", nil)
+	output := repo.ConfigBool("test-name", true)
+	assert.Equal(t, false, output)
+
+	ctrl.EXPECT().
+		Git("", "config", "--get", "test-name").
+		Return("test-string", nil)
+	output = repo.ConfigBool("test-name", true)
+	assert.Equal(t, true, output)
+}
+
+func TestFindTag(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	repo := NewRepo(cli)
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list").Return("", errors.New("test-error"))
+	_, _, err := repo.FindTag(RootModule(), StrategyDescribe)
+	assert.Error(t, err, "test-error")
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list").Return("", nil)
+	tag, tagName, err := repo.FindTag(RootModule(), StrategyDescribe)
+	assert.NoError(t, err)
+	assert.Equal(t, "", tagName)
+	assert.Equal(t, "0.0.0", tag.String())
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list").Return("tools/foo/v1.0.0", nil)
+	tag, tagName, err = repo.FindTag(RootModule(), StrategyDescribe)
+	assert.NoError(t, err)
+	assert.Equal(t, "", tagName)
+	assert.Equal(t, "0.0.0", tag.String())
+
+	tagCall := ctrl.EXPECT().
+		Git("", "tag", "--list").Return("text-tag", nil)
+	ctrl.EXPECT().
+		Git("", "describe", "--tags", "--abbrev=0", "--exclude", "*/*").
+		Return("", errors.New("test-error")).After(tagCall)
+	_, _, err = repo.FindTag(RootModule(), StrategyDescribe)
+	assert.Error(t, err, "test-error")
+
+	tagCall = ctrl.EXPECT().
+		Git("", "tag", "--list").Return("text-tag", nil)
+	describeCall := ctrl.EXPECT().
+		Git("", "describe", "--tags", "--abbrev=0", "--exclude", "*/*").
+		Return("1.2.3", nil).After(tagCall)
+	ctrl.EXPECT().
+		Git("", "tag", "--points-at", "1.2.3^{commit}").
+		Return("1.2.3", nil).After(describeCall)
+	tag, tagName, err = repo.FindTag(RootModule(), StrategyDescribe)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3", tagName)
+	assert.Equal(t, "1.2.3", tag.String())
+
+	tagCall = ctrl.EXPECT().
+		Git("", "tag", "--list").Return("text-tag", nil)
+	ctrl.EXPECT().
+		Git("", "describe", "--tags", "--abbrev=0", "--exclude", "*/*").
+		Return("text-tag", nil).After(tagCall)
+	_, _, err = repo.FindTag(RootModule(), StrategyDescribe)
+	assert.Error(t, err)
+
+	tagCall = ctrl.EXPECT().
+		Git("", "tag", "--list").Return("text-tag", nil)
+	ctrl.EXPECT().
+		Git("", "describe", "--tags", "--abbrev=0", "--exclude", "*/*").
+		Return("v-text-tag", nil).After(tagCall)
+	_, _, err = repo.FindTag(RootModule(), StrategyDescribe)
+	assert.Error(t, err)
+
+	tagCall = ctrl.EXPECT().
+		Git("", "tag", "--list").Return("text-tag", nil)
+	describeCall = ctrl.EXPECT().
+		Git("", "describe", "--tags", "--abbrev=0", "--exclude", "*/*").
+		Return("v1.3.0-rc.2", nil).After(tagCall)
+	ctrl.EXPECT().
+		Git("", "tag", "--points-at", "v1.3.0-rc.2^{commit}").
+		Return("deploy-prod\nv1.3.0\nv1.3.0-rc.2", nil).After(describeCall)
+	tag, tagName, err = repo.FindTag(RootModule(), StrategyDescribe)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.0", tagName)
+	assert.Equal(t, "1.3.0", tag.String())
+
+	tagCall = ctrl.EXPECT().
+		Git("", "tag", "--list").Return("text-tag", nil)
+	describeCall = ctrl.EXPECT().
+		Git("", "describe", "--tags", "--abbrev=0", "--exclude", "*/*").
+		Return("v1.3.0", nil).After(tagCall)
+	ctrl.EXPECT().
+		Git("", "tag", "--points-at", "v1.3.0^{commit}").
+		Return("", errors.New("test-error")).After(describeCall)
+	_, _, err = repo.FindTag(RootModule(), StrategyDescribe)
+	assert.EqualError(t, err, "test-error")
+}
+
+func TestFindTagModule(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	repo := NewRepo(cli)
+	m := ModuleAt("tools/foo")
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list", "tools/foo/v*").Return("", nil)
+	tag, tagName, err := repo.FindTag(m, StrategyDescribe)
+	assert.NoError(t, err)
+	assert.Equal(t, "", tagName)
+	assert.Equal(t, "0.0.0", tag.String())
+
+	tagCall := ctrl.EXPECT().
+		Git("", "tag", "--list", "tools/foo/v*").Return("tools/foo/v1.2.0", nil)
+	describeCall := ctrl.EXPECT().
+		Git("", "describe", "--tags", "--abbrev=0", "--match", "tools/foo/v*").
+		Return("tools/foo/v1.2.0", nil).After(tagCall)
+	ctrl.EXPECT().
+		Git("", "tag", "--points-at", "tools/foo/v1.2.0^{commit}").
+		Return("tools/foo/v1.2.0\nv3.0.0", nil).After(describeCall)
+	tag, tagName, err = repo.FindTag(m, StrategyDescribe)
+	assert.NoError(t, err)
+	assert.Equal(t, "tools/foo/v1.2.0", tagName)
+	assert.Equal(t, "1.2.0", tag.String())
+	assert.Equal(t, "tools/foo/v", m.Prefix)
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list").
+		Return("v3.0.0\ntools/foo/v1.2.0\ntools/foo/v1.10.0\ntools/bar/v4.0.0", nil)
+	tag, tagName, err = repo.FindTag(m, StrategyHighest)
+	assert.NoError(t, err)
+	assert.Equal(t, "tools/foo/v1.10.0", tagName)
+	assert.Equal(t, "1.10.0", tag.String())
+}
+
+func TestFindTagHighest(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	repo := NewRepo(cli)
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list").
+		Return("", errors.New("test-error"))
+	_, _, err := repo.FindTag(RootModule(), StrategyHighest)
+	assert.EqualError(t, err, "test-error")
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list").
+		Return("", nil)
+	tag, tagName, err := repo.FindTag(RootModule(), StrategyHighest)
+	assert.NoError(t, err)
+	assert.Equal(t, "", tagName)
+	assert.Equal(t, "0.0.0", tag.String())
+
+	ctrl.EXPECT().
+		Git("", "tag", "--list").
+		Return("v1.10.0\ndeploy-prod\n2.0.0\nv1.9.0\nv2.0.0-rc.1\nvendor", nil)
+	var warnings []string
+	repo.Warn = func(message string) {
+		warnings = append(warnings, message)
+	}
+	tag, tagName, err = repo.FindTag(RootModule(), StrategyHighest)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2.0.0-rc.1", tagName)
+	assert.Equal(t, "2.0.0-rc.1", tag.String())
+	assert.Equal(t, []string{
+		"skipping the tag 'vendor': invalid version 'endor.0.0': strconv.ParseInt: parsing \"endor\": invalid syntax",
+	}, warnings)
+
+	ctrl.EXPECT().
+		Git("", "tag", "--merged", "HEAD").
+		Return("v1.10.0\nv1.9.0", nil)
+	tag, tagName, err = repo.FindTag(RootModule(), StrategyHighestReachable)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.10.0", tagName)
+	assert.Equal(t, "1.10.0", tag.String())
+
+	_, _, err = repo.FindTag(RootModule(), "test-strategy")
+	assert.EqualError(t, err, "unknown strategy 'test-strategy'")
+	assert.NoError(t, ValidateStrategy(StrategyHighest))
+	assert.EqualError(t, ValidateStrategy("test-strategy"), "unknown strategy 'test-strategy'")
+}
+
+func TestCommits(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	repo := NewRepo(cli)
+
+	ctrl.EXPECT().
+		Git("", "log", "--pretty=%h%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%b%x1e", "--no-merges", "test-tag..HEAD").
+		Return("abc1234\x1fJohn\x1fjohn@example.com\x1f\x1ffeat: test\x1fbody\n\nBREAKING CHANGE: test\x1e\n"+
+			"def5678\x1fJane\x1fjane@example.com\x1f\x1ffix: test\x1f\x1e", nil)
+	commits, err := repo.Commits("test-tag", RootModule())
+	assert.NoError(t, err)
+	assert.Equal(t, []*Commit{
+		{
+			Hash:        "abc1234",
+			Author:      "John",
+			AuthorEmail: "john@example.com",
+			Subject:     "feat: test",
+			Body:        "body\n\nBREAKING CHANGE: test",
+			Trailers:    []Trailer{{Key: "BREAKING CHANGE", Value: "test"}},
+		},
+		{Hash: "def5678", Author: "Jane", AuthorEmail: "jane@example.com", Subject: "fix: test"},
+	}, commits)
+
+	ctrl.EXPECT().
+		Git("", "log", "--pretty=%h%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%b%x1e", "--no-merges").
+		Return("", errors.New("test-error"))
+	_, err = repo.Commits("", RootModule())
+	assert.EqualError(t, err, "test-error")
+}
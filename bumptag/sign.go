@@ -0,0 +1,62 @@
+package bumptag
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// The signature formats of the `gpg.format` git config option.
+const (
+	SignFormatOpenPGP = "openpgp"
+	SignFormatSSH     = "ssh"
+	SignFormatX509    = "x509"
+)
+
+// signTags returns the value of `tag.gpgSign`, the value of `commit.gpgsign` if it is not set.
+func (r *Repo) signTags() bool {
+	if value, err := strconv.ParseBool(r.Config("tag.gpgSign", "")); err == nil {
+		return value
+	}
+	return r.ConfigBool("commit.gpgsign", false)
+}
+
+// checkSigning checks the tag can be signed in the format of `gpg.format` and its signature can be verified,
+// so the release fails before the commits of the version files.
+func (t *Tagger) checkSigning() error {
+	if !t.Sign {
+		return nil
+	}
+	switch format := t.Repo.Config("gpg.format", SignFormatOpenPGP); format {
+	case SignFormatOpenPGP, SignFormatX509:
+		return nil
+	case SignFormatSSH:
+		if len(t.SignKey) == 0 && len(t.Repo.Config("user.signingkey", "")) == 0 &&
+			len(t.Repo.Config("gpg.ssh.defaultKeyCommand", "")) == 0 {
+			return errors.New("the ssh signing requires a key, set user.signingkey or the sign-key setting")
+		}
+		if len(t.Repo.Config("gpg.ssh.allowedSignersFile", "")) == 0 {
+			return errors.New("the ssh signatures cannot be verified, set gpg.ssh.allowedSignersFile")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown signature format '%s' of gpg.format", format)
+	}
+}
+
+// createTag creates the tag of the release, records it and verifies its signature if it is signed.
+func (t *Tagger) createTag(r *Release) error {
+	if err := t.Repo.Backend.CreateTag(r.TagName, r.Annotation, t.Sign, t.SignKey); err != nil {
+		return err
+	}
+	t.record(fmt.Sprintf("the tag '%s'", r.TagName), func() error {
+		return t.Repo.Backend.DeleteTag(r.TagName)
+	})
+	if !t.Sign {
+		return nil
+	}
+	if err := t.Repo.Backend.VerifyTag(r.TagName); err != nil {
+		return fmt.Errorf("the signature of the tag '%s' is bad: %w", r.TagName, err)
+	}
+	return nil
+}
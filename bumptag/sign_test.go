@@ -0,0 +1,89 @@
+package bumptag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaggerCheckSigning(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	notSet := errors.New("test-error")
+	tagger := &Tagger{Repo: NewRepo(cli)}
+	assert.NoError(t, tagger.checkSigning(), "no config is read if the tags are not signed")
+
+	tagger.Sign = true
+	ctrl.EXPECT().
+		Git("", "config", "--get", "gpg.format").
+		Return(SignFormatX509, nil)
+	assert.NoError(t, tagger.checkSigning())
+
+	ctrl.EXPECT().
+		Git("", "config", "--get", "gpg.format").
+		Return("gpg2", nil)
+	assert.EqualError(t, tagger.checkSigning(), "unknown signature format 'gpg2' of gpg.format")
+
+	ctrl.EXPECT().
+		Git("", "config", "--get", "gpg.format").
+		Return(SignFormatSSH, nil)
+	ctrl.EXPECT().
+		Git("", "config", "--get", "user.signingkey").
+		Return("", notSet)
+	ctrl.EXPECT().
+		Git("", "config", "--get", "gpg.ssh.defaultKeyCommand").
+		Return("", notSet)
+	assert.EqualError(
+		t,
+		tagger.checkSigning(),
+		"the ssh signing requires a key, set user.signingkey or the sign-key setting",
+	)
+
+	tagger.SignKey = "~/.ssh/id_ed25519.pub"
+	ctrl.EXPECT().
+		Git("", "config", "--get", "gpg.format").
+		Return(SignFormatSSH, nil)
+	ctrl.EXPECT().
+		Git("", "config", "--get", "gpg.ssh.allowedSignersFile").
+		Return("", notSet)
+	assert.EqualError(
+		t,
+		tagger.checkSigning(),
+		"the ssh signatures cannot be verified, set gpg.ssh.allowedSignersFile",
+	)
+
+	ctrl.EXPECT().
+		Git("", "config", "--get", "gpg.format").
+		Return(SignFormatSSH, nil)
+	ctrl.EXPECT().
+		Git("", "config", "--get", "gpg.ssh.allowedSignersFile").
+		Return("~/.ssh/allowed_signers", nil)
+	assert.NoError(t, tagger.checkSigning())
+}
+
+func TestTaggerBadSignature(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	tagger := &Tagger{Repo: NewRepo(cli), Sign: true, SignKey: "test-key", SkipChecks: Checks()}
+	r := &Release{Module: RootModule(), Version: mustVersion("0.3.0"), TagName: "v0.3.0", Annotation: "test"}
+
+	ctrl.EXPECT().
+		Git("", "config", "--get", "gpg.format").
+		Return("", errors.New("test-error"))
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:go.mod").
+		Return("module example.com/foo", nil)
+	ctrl.EXPECT().
+		Git("test", "tag", "-F-", "--local-user", "test-key", "v0.3.0").
+		Return("", nil)
+	ctrl.EXPECT().
+		Git("", "tag", "--verify", "v0.3.0").
+		Return("", errors.New("test-error"))
+	assert.EqualError(t, tagger.Tag(r), "the signature of the tag 'v0.3.0' is bad: test-error")
+
+	ctrl.EXPECT().
+		Git("", "tag", "--delete", "v0.3.0").
+		Return("", nil)
+	undone, err := tagger.Rollback()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"the tag 'v0.3.0'"}, undone)
+}
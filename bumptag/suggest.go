@@ -0,0 +1,153 @@
+package bumptag
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/apidiff"
+	"golang.org/x/tools/go/packages"
+)
+
+func isInternalPackage(path string) bool {
+	for _, name := range strings.Split(path, "/") {
+		if name == "internal" {
+			return true
+		}
+	}
+	return false
+}
+
+// loadModuleAPI type-checks the importable packages of the Go module in the directory.
+func loadModuleAPI(dir string) (*apidiff.Module, error) {
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+		return nil, fmt.Errorf("the directory '%s' is not a Go module: %w", dir, err)
+	}
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedModule,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, err
+	}
+	res := &apidiff.Module{}
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, fmt.Errorf("cannot load the package '%s': %w", pkg.PkgPath, pkg.Errors[0])
+		}
+		if pkg.Module != nil {
+			res.Path = pkg.Module.Path
+		}
+		if pkg.Name == "main" || isInternalPackage(pkg.PkgPath) {
+			continue
+		}
+		res.Packages = append(res.Packages, pkg.Types)
+	}
+	return res, nil
+}
+
+func (r *Repo) loadModuleAPIAt(m *Module, rev string) (*apidiff.Module, error) {
+	dir, cleanUp, err := r.Backend.Checkout(rev)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanUp()
+	return loadModuleAPI(filepath.Join(dir, filepath.FromSlash(m.Path)))
+}
+
+// suggestBumpLevel recommends MAJOR for the incompatible changes (MINOR for 0.x versions),
+// MINOR for the compatible changes and PATCH if the API is not changed.
+func suggestBumpLevel(v *Version, report apidiff.Report) BumpLevel {
+	level := BumpPatch
+	for _, change := range report.Changes {
+		if !change.Compatible {
+			level = BumpMajor
+			break
+		}
+		level = BumpMinor
+	}
+	if level == BumpMajor && v.Major == 0 {
+		level = BumpMinor
+	}
+	return level
+}
+
+// Suggestion is the bump level recommended by the changes of the exported API of a Go module.
+type Suggestion struct {
+	Module *Module
+	// Tag is the name of the latest tag the API is compared with.
+	Tag     string
+	Version *Version
+	Level   BumpLevel
+	// Next is the version bumped by the suggested level.
+	Next   *Version
+	Report apidiff.Report
+}
+
+func (s *Suggestion) String() string {
+	output := []string{
+		fmt.Sprintf("The latest tag: %s", s.Tag),
+		fmt.Sprintf("Suggested bump: %s (%s)", strings.ToUpper(s.Level.String()), s.Module.TagName(s.Next)),
+	}
+
+	changes := append([]apidiff.Change(nil), s.Report.Changes...)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Message < changes[j].Message
+	})
+	for _, compatible := range []bool{false, true} {
+		var lines []string
+		for _, change := range changes {
+			if change.Compatible == compatible {
+				lines = append(lines, "* "+change.Message)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		title := "Incompatible changes:"
+		if compatible {
+			title = "Compatible changes:"
+		}
+		output = append(output, "", title)
+		output = append(output, lines...)
+	}
+	return strings.Join(output, "\n")
+}
+
+// Suggest compares the exported API of the Go module at the latest tag and at HEAD and recommends the bump level.
+func (r *Repo) Suggest(m *Module, strategy string) (*Suggestion, error) {
+	v, tagName, err := r.FindTag(m, strategy)
+	if err != nil {
+		return nil, err
+	}
+	if len(tagName) == 0 {
+		return nil, errors.New("no tags found to compare the API with")
+	}
+	oldAPI, err := r.loadModuleAPIAt(m, tagName)
+	if err != nil {
+		return nil, err
+	}
+	newAPI, err := r.loadModuleAPIAt(m, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return newSuggestion(m, tagName, v, apidiff.ModuleChanges(oldAPI, newAPI)), nil
+}
+
+func newSuggestion(m *Module, tagName string, v *Version, report apidiff.Report) *Suggestion {
+	level := suggestBumpLevel(v, report)
+	next := *v
+	next.Bump(level)
+	return &Suggestion{
+		Module:  m,
+		Tag:     tagName,
+		Version: v,
+		Level:   level,
+		Next:    &next,
+		Report:  report,
+	}
+}
@@ -0,0 +1,99 @@
+package bumptag
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/apidiff"
+)
+
+func TestIsInternalPackage(t *testing.T) {
+	assert.True(t, isInternalPackage("example.com/foo/internal"))
+	assert.True(t, isInternalPackage("example.com/foo/internal/bar"))
+	assert.False(t, isInternalPackage("example.com/foo/internals"))
+}
+
+func TestSuggestBumpLevel(t *testing.T) {
+	tag := mustVersion("1.2.0")
+	assert.Equal(t, BumpPatch, suggestBumpLevel(tag, apidiff.Report{}))
+
+	report := apidiff.Report{Changes: []apidiff.Change{{Message: "Foo: added", Compatible: true}}}
+	assert.Equal(t, BumpMinor, suggestBumpLevel(tag, report))
+
+	report.Changes = append(report.Changes, apidiff.Change{Message: "Bar: removed"})
+	assert.Equal(t, BumpMajor, suggestBumpLevel(tag, report))
+	assert.Equal(t, BumpMinor, suggestBumpLevel(mustVersion("0.2.0"), report))
+}
+
+func TestSuggestionString(t *testing.T) {
+	report := apidiff.Report{Changes: []apidiff.Change{
+		{Message: "Foo: added", Compatible: true},
+		{Message: "Bar: removed"},
+		{Message: "Baz: changed from func() to func(int)"},
+	}}
+	output := newSuggestion(RootModule(), "v1.2.0", mustVersion("1.2.0"), report).String()
+	assert.Equal(t, `The latest tag: v1.2.0
+Suggested bump: MAJOR (v2.0.0)
+
+Incompatible changes:
+* Bar: removed
+* Baz: changed from func() to func(int)
+
+Compatible changes:
+* Foo: added`, output)
+
+	output = newSuggestion(RootModule(), "v1.2.0", mustVersion("1.2.0"), apidiff.Report{}).String()
+	assert.Equal(t, "The latest tag: v1.2.0\nSuggested bump: PATCH (v1.2.1)", output)
+}
+
+func TestSuggest(t *testing.T) {
+	_, repo := prepareGit(t)
+
+	_, err := repo.Suggest(RootModule(), StrategyDescribe)
+	assert.EqualError(t, err, "no tags found to compare the API with")
+
+	dir := prepareModule(t, "tools/foo")
+	m, err := repo.Module(dir)
+	assert.NoError(t, err)
+	commitFile := func(content string) {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo.go"), []byte(content), 0o600))
+		_, err := git("", "add", filepath.Join("tools", "foo", "foo.go"))
+		assert.NoError(t, err)
+		_, err = git("", "commit", "-m", "Update foo.go")
+		assert.NoError(t, err)
+	}
+
+	commitFile("package foo\n\nfunc Foo() {}\n")
+	_, err = git("", "tag", "tools/foo/v1.0.0")
+	assert.NoError(t, err)
+
+	commitFile("package foo\n\nfunc Foo() {}\n\nfunc foo() {}\n")
+	s, err := repo.Suggest(m, StrategyDescribe)
+	assert.NoError(t, err)
+	assert.Equal(t, BumpPatch, s.Level)
+	assert.Contains(t, s.String(), "Suggested bump: PATCH (tools/foo/v1.0.1)")
+
+	commitFile("package foo\n\nfunc Foo() {}\n\nfunc Bar() {}\n")
+	s, err = repo.Suggest(m, StrategyDescribe)
+	assert.NoError(t, err)
+	assert.Equal(t, BumpMinor, s.Level)
+	assert.Contains(t, s.String(), "Suggested bump: MINOR (tools/foo/v1.1.0)")
+	assert.Contains(t, s.String(), "* Bar: added")
+
+	commitFile("package foo\n\nfunc Foo(int) {}\n")
+	s, err = repo.Suggest(m, StrategyDescribe)
+	assert.NoError(t, err)
+	assert.Equal(t, BumpMajor, s.Level)
+	assert.Contains(t, s.String(), "Suggested bump: MAJOR (tools/foo/v2.0.0)")
+	assert.Contains(t, s.String(), "Incompatible changes:")
+
+	commitFile("package foo\n\nfunc Foo( {}\n")
+	_, err = repo.Suggest(m, StrategyDescribe)
+	assert.Error(t, err)
+
+	output, err := git("", "worktree", "list")
+	assert.NoError(t, err)
+	assert.NotContains(t, output, "worktree")
+}
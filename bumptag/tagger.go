@@ -0,0 +1,209 @@
+package bumptag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Tagger creates and pushes the tags of the releases.
+type Tagger struct {
+	Repo *Repo
+	// Sign signs the tags in the format of `gpg.format`: openpgp (GPG), ssh or x509,
+	// the signatures are verified after the tags are created.
+	Sign bool
+	// SignKey is the key to sign the tags with, the value of `user.signingkey` if empty.
+	SignKey string
+	// Remote is the remote to push the tags to, the remote of the current branch if empty.
+	Remote string
+	// AutoPush tells the tags are pushed after they are created, so a missing remote fails the tag check
+	// before any change of the repository, otherwise the remote tags are not checked without the remote.
+	AutoPush bool
+	// FixModulePath updates the module path in go.mod and the imports in a new commit
+	// when the MAJOR version does not match the module path, otherwise such tags are refused.
+	FixModulePath bool
+	// ChangelogFile is the change log file to add the entry of the release to and to commit before tagging,
+	// see ChangelogEntry. The path is relative to the root of the repository, the file is not updated if empty.
+	ChangelogFile string
+	// BumpFiles are the files to update the version in and to commit before tagging, see BumpVersion.
+	// The paths are relative to the root of the repository.
+	BumpFiles []string
+	// Replacements are the rules writing the version into the files, the changed files are committed
+	// together with BumpFiles.
+	Replacements []*Replacement
+	// BumpMessage is the template of the message of the commit of BumpFiles, DefaultBumpMessage if empty.
+	BumpMessage string
+	// Hooks are the shell commands to run at the stages of the release.
+	Hooks Hooks
+	// Branches are the glob patterns of the release branches, e.g. `release/*`, see CheckBranch.
+	// Any branch is allowed if empty.
+	Branches []string
+	// SkipChecks are the names of the pre-flight checks not to run, see Check.
+	SkipChecks []string
+
+	mu sync.Mutex
+	// changes are the changes of the repository made by Tag, they are undone by Rollback.
+	changes []change
+}
+
+// change is a change of the repository with the function to undo it.
+type change struct {
+	description string
+	undo        func() error
+}
+
+// NewTagger returns a tagger of the repository signing the tags if `tag.gpgSign` is enabled,
+// `commit.gpgsign` if it is not set.
+func NewTagger(repo *Repo) *Tagger {
+	return &Tagger{
+		Repo: repo,
+		Sign: repo.signTags(),
+	}
+}
+
+// Tag runs the pre-flight checks and creates the annotated tag of the release at HEAD,
+// after the commits of the version files and the change log file if they are set, and runs the hooks.
+// The changes are recorded, call Rollback if Tag or Push fails and Forget when the release is done.
+func (t *Tagger) Tag(r *Release) error {
+	if err := t.Check(r); err != nil {
+		return err
+	}
+	if err := t.checkSigning(); err != nil {
+		return err
+	}
+	if err := t.guardModulePath(r); err != nil {
+		return err
+	}
+	hooked, err := t.runPreBump(r)
+	if err != nil {
+		return err
+	}
+	if len(t.BumpFiles) > 0 || len(t.Replacements) > 0 || len(hooked) > 0 {
+		if err := t.bumpFiles(r, hooked); err != nil {
+			return err
+		}
+	}
+	if len(t.ChangelogFile) > 0 {
+		if err := t.updateChangelogFile(r); err != nil {
+			return err
+		}
+	}
+	if err := t.runHook(HookPreTag, t.Hooks.PreTag, r); err != nil {
+		return err
+	}
+	if err := t.createTag(r); err != nil {
+		return err
+	}
+	return t.runHook(HookPostTag, t.Hooks.PostTag, r)
+}
+
+// record adds the change to undo by Rollback.
+func (t *Tagger) record(description string, undo func() error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.changes = append(t.changes, change{description: description, undo: undo})
+}
+
+// commit commits the files and records the commit, the staged files are recorded if the commit fails.
+func (t *Tagger) commit(message string, files []string) error {
+	head, err := t.Repo.Backend.ShortHash("HEAD")
+	if err != nil {
+		return err
+	}
+	err = t.Repo.Backend.Commit(message, files)
+	description := fmt.Sprintf("the commit '%s'", message)
+	if err != nil {
+		description = "the staged files: " + strings.Join(files, ", ")
+	}
+	t.record(description, func() error {
+		return t.Repo.Backend.Reset(head)
+	})
+	return err
+}
+
+// Rollback undoes the changes made by Tag in the reverse order: deletes the tag, removes the commits and restores
+// the changed files, so the next run computes the same version. It is safe to call from another goroutine,
+// e.g. on Ctrl-C. It returns the descriptions of the undone changes, e.g. `the tag 'v1.2.0'`,
+// the changes failed to undo are reported by the error.
+func (t *Tagger) Rollback() ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var undone []string
+	var errs []error
+	for i := len(t.changes) - 1; i >= 0; i-- {
+		c := t.changes[i]
+		if err := c.undo(); err != nil {
+			errs = append(errs, fmt.Errorf("cannot roll back %s: %w", c.description, err))
+			continue
+		}
+		undone = append(undone, c.description)
+	}
+	t.changes = nil
+	return undone, errors.Join(errs...)
+}
+
+// Forget forgets the recorded changes, so they are kept by Rollback, e.g. after the tag is pushed.
+func (t *Tagger) Forget() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.changes = nil
+}
+
+// guardCleanTree refuses to commit the files of the paths if they have the uncommitted changes,
+// so the changes of the user are not committed together with the generated ones.
+// The paths are relative to the root, the empty path is the whole repository.
+func (t *Tagger) guardCleanTree(paths ...string) error {
+	files, err := t.Repo.Backend.ChangedFiles()
+	if err != nil {
+		return err
+	}
+	var dirty []string
+	for _, name := range files {
+		for _, p := range paths {
+			if len(p) == 0 || name == p || strings.HasPrefix(name, p+"/") {
+				dirty = append(dirty, name)
+				break
+			}
+		}
+	}
+	if len(dirty) > 0 {
+		return fmt.Errorf("%w: %s", ErrDirtyTree, strings.Join(dirty, ", "))
+	}
+	return nil
+}
+
+// remote returns the remote to push the tags to, the remote of the current branch by default.
+func (t *Tagger) remote() (string, error) {
+	remote := t.Remote
+	if len(remote) == 0 {
+		var err error
+		if remote, err = t.Repo.Backend.Remote(); err != nil {
+			return "", err
+		}
+	}
+	if !strings.ContainsAny(remote, ":/") {
+		// the remote can be an URL or a path, only the names are checked
+		if _, err := t.Repo.Backend.Config("remote." + remote + ".url"); err != nil {
+			return "", fmt.Errorf("%w: the remote '%s' is not configured", ErrNoRemote, remote)
+		}
+	}
+	return remote, nil
+}
+
+// Push pushes the tag of the release to the remote, runs the post-push hook and returns the name of the remote.
+// The pushed changes are forgotten, so they are not rolled back if the hook fails.
+func (t *Tagger) Push(r *Release) (string, error) {
+	remote, err := t.remote()
+	if err != nil {
+		return "", err
+	}
+	if err := t.Repo.Backend.PushTag(remote, r.TagName); err != nil {
+		return "", err
+	}
+	t.Forget()
+	if err := t.runHook(HookPostPush, t.Hooks.PostPush, r); err != nil {
+		return remote, fmt.Errorf("the tag '%s' has been pushed, but %w", r.TagName, err)
+	}
+	return remote, nil
+}
@@ -0,0 +1,178 @@
+package bumptag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTagger(t *testing.T) {
+	ctrl, cli := mockGit(t)
+
+	ctrl.EXPECT().
+		Git("", "config", "--get", "tag.gpgSign").
+		Return("", errors.New("test-error"))
+	ctrl.EXPECT().
+		Git("", "config", "--get", "commit.gpgsign").
+		Return("true", nil)
+	tagger := NewTagger(NewRepo(cli))
+	assert.True(t, tagger.Sign)
+	assert.False(t, tagger.FixModulePath)
+
+	ctrl.EXPECT().
+		Git("", "config", "--get", "tag.gpgSign").
+		Return("false", nil)
+	assert.False(t, NewTagger(NewRepo(cli)).Sign, "tag.gpgSign wins over commit.gpgsign")
+}
+
+func TestTaggerTag(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	tagger := &Tagger{Repo: NewRepo(cli), Sign: true, SkipChecks: []string{CheckBranch, CheckBehind, CheckTag}}
+
+	r := &Release{
+		Module:          RootModule(),
+		PreviousTag:     "v1.2.0",
+		PreviousVersion: *mustVersion("1.2.0"),
+		Version:         mustVersion("1.3.0"),
+		TagName:         "v1.3.0",
+		Annotation:      "test-annotation",
+	}
+	ctrl.EXPECT().
+		Git("", "diff", "--name-only", "HEAD").
+		Return("", nil)
+	ctrl.EXPECT().
+		Git("", "config", "--get", "gpg.format").
+		Return("", errors.New("test-error")).Times(2)
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:go.mod").
+		Return("module example.com/foo", nil)
+	ctrl.EXPECT().
+		Git("test-annotation", "tag", "-F-", "--sign", "v1.3.0").
+		Return("", nil)
+	ctrl.EXPECT().
+		Git("", "tag", "--verify", "v1.3.0").
+		Return("", nil)
+	assert.NoError(t, tagger.Tag(r))
+
+	ctrl.EXPECT().
+		Git("", "diff", "--name-only", "HEAD").
+		Return("README.md", nil)
+	err := tagger.Tag(r)
+	assert.EqualError(t, err, "the clean check failed: the working tree has uncommitted changes: README.md")
+	assert.ErrorIs(t, err, ErrDirtyTree)
+	assert.ErrorIs(t, err, ErrCheckFailed)
+
+	tagger.SkipChecks = Checks()
+	r.Version = mustVersion("2.0.0")
+	r.TagName = "v2.0.0"
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:go.mod").
+		Return("module example.com/foo", nil)
+	assert.ErrorIs(t, tagger.Tag(r), ErrInvalidVersion)
+}
+
+func TestTaggerRollback(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	tagger := &Tagger{Repo: NewRepo(cli), SkipChecks: Checks()}
+	r := &Release{Module: RootModule(), Version: mustVersion("0.3.0"), TagName: "v0.3.0", Annotation: "test"}
+
+	ctrl.EXPECT().
+		Git("", "show", "HEAD:go.mod").
+		Return("module example.com/foo", nil).Times(2)
+	ctrl.EXPECT().
+		Git("test", "tag", "-F-", "--no-sign", "v0.3.0").
+		Return("", nil).Times(2)
+	assert.NoError(t, tagger.Tag(r))
+	ctrl.EXPECT().
+		Git("", "tag", "--delete", "v0.3.0").
+		Return("", nil)
+	undone, err := tagger.Rollback()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"the tag 'v0.3.0'"}, undone)
+	undone, err = tagger.Rollback()
+	assert.NoError(t, err)
+	assert.Empty(t, undone, "the changes are rolled back once")
+
+	assert.NoError(t, tagger.Tag(r))
+	tagger.Forget()
+	undone, err = tagger.Rollback()
+	assert.NoError(t, err)
+	assert.Empty(t, undone)
+
+	headCall := ctrl.EXPECT().
+		Git("", "rev-parse", "--short", "HEAD").
+		Return("cc51028", nil)
+	ctrl.EXPECT().
+		Git("", "add", "--", ":(top)go.mod").
+		Return("", errors.New("test-error")).After(headCall)
+	assert.EqualError(t, tagger.commit("test-message", []string{"go.mod"}), "test-error")
+	ctrl.EXPECT().
+		Git("", "reset", "--quiet", "--keep", "cc51028").
+		Return("", errors.New("test-error"))
+	undone, err = tagger.Rollback()
+	assert.Empty(t, undone)
+	assert.EqualError(t, err, "cannot roll back the staged files: go.mod: test-error")
+}
+
+func TestTaggerPush(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	tagger := &Tagger{Repo: NewRepo(cli)}
+	r := &Release{TagName: "v1.3.0"}
+
+	branchCall := ctrl.EXPECT().
+		Git("", "branch", "--list", "-vv").
+		Return("* master cc51028 [test-origin/master] test", nil)
+	configCall := ctrl.EXPECT().
+		Git("", "config", "--get", "remote.test-origin.url").
+		Return("git@example.com:test/test.git", nil).After(branchCall)
+	ctrl.EXPECT().
+		Git("", "push", "test-origin", "v1.3.0").
+		Return("", nil).After(configCall)
+	remote, err := tagger.Push(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-origin", remote)
+
+	ctrl.EXPECT().
+		Git("", "branch", "--list", "-vv").
+		Return("", errors.New("test-error"))
+	_, err = tagger.Push(r)
+	assert.EqualError(t, err, "test-error")
+
+	ctrl.EXPECT().
+		Git("", "branch", "--list", "-vv").
+		Return("* master cc51028 test", nil)
+	_, err = tagger.Push(r)
+	assert.ErrorIs(t, err, ErrNoRemote)
+
+	tagger.Remote = "test-remote"
+	ctrl.EXPECT().
+		Git("", "config", "--get", "remote.test-remote.url").
+		Return("", errors.New("test-error"))
+	_, err = tagger.Push(r)
+	assert.EqualError(t, err, "no remote: the remote 'test-remote' is not configured")
+	assert.ErrorIs(t, err, ErrNoRemote)
+
+	tagger.Remote = "/srv/git/test.git"
+	ctrl.EXPECT().
+		Git("", "push", "/srv/git/test.git", "v1.3.0").
+		Return("", nil)
+	remote, err = tagger.Push(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "/srv/git/test.git", remote)
+}
+
+func TestTaggerGuardCleanTree(t *testing.T) {
+	ctrl, cli := mockGit(t)
+	tagger := &Tagger{Repo: NewRepo(cli)}
+
+	ctrl.EXPECT().
+		Git("", "diff", "--name-only", "HEAD").
+		Return("README.md\ntools/foo/go.mod\ntools/foobar/go.mod", nil).
+		Times(3)
+	assert.NoError(t, tagger.guardCleanTree("CHANGELOG.md"))
+	err := tagger.guardCleanTree("tools/foo")
+	assert.EqualError(t, err, "the working tree has uncommitted changes: tools/foo/go.mod")
+	assert.ErrorIs(t, err, ErrDirtyTree)
+	assert.ErrorIs(t, tagger.guardCleanTree(""), ErrDirtyTree)
+}
@@ -0,0 +1,120 @@
+package bumptag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+)
+
+// BumpLevel is the part of the version to increment.
+type BumpLevel int
+
+// The bump levels.
+const (
+	BumpPatch BumpLevel = iota
+	BumpMinor
+	BumpMajor
+)
+
+func (l BumpLevel) String() string {
+	switch l {
+	case BumpMajor:
+		return "major"
+	case BumpMinor:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// ParseBumpLevel parses the name of the bump level: `major`, `minor` or `patch`.
+func ParseBumpLevel(name string) (BumpLevel, error) {
+	for _, level := range []BumpLevel{BumpMajor, BumpMinor, BumpPatch} {
+		if strings.EqualFold(name, level.String()) {
+			return level, nil
+		}
+	}
+	return BumpPatch, fmt.Errorf("unknown bump level '%s'", name)
+}
+
+// Version is a Semantic Version 2.0.0 of a tag, see https://semver.org
+type Version struct {
+	semver.Version
+}
+
+// NewVersion parses the version strictly, e.g. `1.2.3-rc.1+build.5`.
+func NewVersion(version string) (*Version, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("%w '%s': %s", ErrInvalidVersion, version, err)
+	}
+	return &Version{Version: *v}, nil
+}
+
+// ParseVersion parses the name of the tag without the prefix, the missing MINOR and PATCH versions are zeros,
+// e.g. `v1.2` is 1.2.0.
+func ParseVersion(tagName, prefix string) (*Version, error) {
+	name := strings.TrimPrefix(tagName, prefix)
+	core := name
+	var suffix string
+	if i := strings.IndexAny(name, "-+"); i >= 0 {
+		core, suffix = name[:i], name[i:]
+	}
+	dotParts := strings.SplitN(core, ".", 3)
+	for i := 3 - len(dotParts); i > 0; i-- {
+		core += ".0"
+	}
+	return NewVersion(core + suffix)
+}
+
+// LessThan compares the versions ignoring the build metadata.
+func (v *Version) LessThan(other *Version) bool {
+	return v.Version.LessThan(other.Version)
+}
+
+// Bump increments the version and clears the pre-release and the build metadata.
+// A pre-release of the same level is finalised instead like npm does, so it is released,
+// e.g. v0.2.0-rc.2 -> v0.2.0 for MINOR and PATCH, but v0.2.0-rc.2 -> v1.0.0 for MAJOR.
+func (v *Version) Bump(level BumpLevel) {
+	if len(v.PreRelease) > 0 && v.isPreReleaseOf(level) {
+		v.PreRelease = ""
+		v.Metadata = ""
+		return
+	}
+	v.increment(level)
+}
+
+// isPreReleaseOf checks if the version was incremented by the level before the pre-release was added.
+func (v *Version) isPreReleaseOf(level BumpLevel) bool {
+	switch level {
+	case BumpMajor:
+		return v.Minor == 0 && v.Patch == 0
+	case BumpMinor:
+		return v.Patch == 0
+	default:
+		return true
+	}
+}
+
+// increment increments the version and clears the pre-release and the build metadata.
+func (v *Version) increment(level BumpLevel) {
+	switch level {
+	case BumpMajor:
+		v.BumpMajor()
+	case BumpMinor:
+		v.BumpMinor()
+	default:
+		v.BumpPatch()
+	}
+}
+
+// Promote turns a pre-release into the final release, e.g. v1.3.0-rc.2 -> v1.3.0.
+func (v *Version) Promote() error {
+	if len(v.PreRelease) == 0 {
+		return fmt.Errorf("%w: the version '%s' is not a pre-release", ErrInvalidVersion, v)
+	}
+	v.PreRelease = ""
+	v.Metadata = ""
+	return nil
+}
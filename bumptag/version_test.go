@@ -0,0 +1,105 @@
+package bumptag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustVersion(version string) *Version {
+	v, err := NewVersion(version)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestBumpLevelString(t *testing.T) {
+	assert.Equal(t, "major", BumpMajor.String())
+	assert.Equal(t, "minor", BumpMinor.String())
+	assert.Equal(t, "patch", BumpPatch.String())
+}
+
+func TestParseBumpLevel(t *testing.T) {
+	level, err := ParseBumpLevel("MAJOR")
+	assert.NoError(t, err)
+	assert.Equal(t, BumpMajor, level)
+
+	level, err = ParseBumpLevel("patch")
+	assert.NoError(t, err)
+	assert.Equal(t, BumpPatch, level)
+
+	_, err = ParseBumpLevel("huge")
+	assert.EqualError(t, err, "unknown bump level 'huge'")
+}
+
+func TestNewVersion(t *testing.T) {
+	v, err := NewVersion("1.2.3-rc.1+build.5")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3-rc.1+build.5", v.String())
+
+	_, err = NewVersion("1.2")
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+}
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("v1.2", "v")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.0", v.String())
+
+	v, err = ParseVersion("v1.2.3-rc.1", "v")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3-rc.1", v.String())
+
+	v, err = ParseVersion("v1.2+build.5", "v")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.0+build.5", v.String())
+
+	v, err = ParseVersion("tools/foo/v3", "tools/foo/v")
+	assert.NoError(t, err)
+	assert.Equal(t, "3.0.0", v.String())
+
+	_, err = ParseVersion("deploy-prod", "v")
+	assert.Error(t, err)
+}
+
+func TestVersionLessThan(t *testing.T) {
+	assert.True(t, mustVersion("1.3.0-rc.1").LessThan(mustVersion("1.3.0")))
+	assert.False(t, mustVersion("1.3.0").LessThan(mustVersion("1.3.0+build.1")))
+}
+
+func TestVersionBump(t *testing.T) {
+	v := mustVersion("1.2.3")
+	v.Bump(BumpPatch)
+	assert.Equal(t, "1.2.4", v.String())
+	v.Bump(BumpMinor)
+	assert.Equal(t, "1.3.0", v.String())
+	v.Bump(BumpMajor)
+	assert.Equal(t, "2.0.0", v.String())
+
+	for _, tc := range []struct {
+		version  string
+		level    BumpLevel
+		expected string
+	}{
+		{"0.2.0-rc.2+build.1", BumpPatch, "0.2.0"},
+		{"0.2.0-rc.2", BumpMinor, "0.2.0"},
+		{"0.2.0-rc.2", BumpMajor, "1.0.0"},
+		{"1.2.3-rc.1", BumpPatch, "1.2.3"},
+		{"1.2.3-rc.1", BumpMinor, "1.3.0"},
+		{"2.0.0-beta.1", BumpMajor, "2.0.0"},
+	} {
+		v := mustVersion(tc.version)
+		v.Bump(tc.level)
+		assert.Equal(t, tc.expected, v.String(), "%s %s", tc.version, tc.level)
+	}
+}
+
+func TestVersionPromote(t *testing.T) {
+	v := mustVersion("1.3.0-rc.2+build.1")
+	assert.NoError(t, v.Promote())
+	assert.Equal(t, "1.3.0", v.String())
+
+	assert.EqualError(t, v.Promote(), "invalid version: the version '1.3.0' is not a pre-release")
+	assert.ErrorIs(t, v.Promote(), ErrInvalidVersion)
+}
@@ -2,84 +2,26 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"reflect"
 	"strings"
 	"syscall"
 	"testing"
 	"time"
 
-	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
-)
-
-// MockGit is a mock of MockGit function
-type MockGit struct {
-	ctrl     *gomock.Controller
-	recorder *MockGitMockRecorder
-}
-
-// MockGitMockRecorder is the mock recorder for MockGit
-type MockGitMockRecorder struct {
-	mock *MockGit
-}
-
-// NewMockGit creates a new mock instance
-func NewMockGit(ctrl *gomock.Controller) *MockGit {
-	mock := &MockGit{ctrl: ctrl}
-	mock.recorder = &MockGitMockRecorder{mock}
-	return mock
-}
 
-// EXPECT returns an object that allows the caller to indicate expected use
-func (m *MockGit) EXPECT() *MockGitMockRecorder {
-	return m.recorder
-}
-
-// Git mocks base method
-func (m *MockGit) Git(arg0 string, arg1 ...string) (string, error) {
-	m.ctrl.T.Helper()
-	msg := fmt.Sprintf("git %s", strings.Join(arg1, " "))
-	if len(arg0) > 0 {
-		msg += "\nInput: " + arg0
-	}
-	m.ctrl.T.(testing.TB).Log(msg)
-	varargs := []interface{}{arg0}
-	for _, a := range arg1 {
-		varargs = append(varargs, a)
-	}
-	ret := m.ctrl.Call(m, "Git", varargs...)
-	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// Git indicates an expected call of Git
-func (mr *MockGitMockRecorder) Git(arg0 string, arg1 ...string) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	varargs := []interface{}{arg0}
-	for _, a := range arg1 {
-		varargs = append(varargs, a)
-	}
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Git", reflect.TypeOf((*MockGit)(nil).Git), varargs...)
-}
-
-func mockGit(t testing.TB) (*MockGit, func()) {
-	ctrl := gomock.NewController(t)
-	mockGit := NewMockGit(ctrl)
-	git = mockGit.Git
-	return mockGit, func() {
-		ctrl.Finish()
-		git = realGit
-	}
-}
+	"github.com/sv-tools/bumptag/bumptag"
+)
 
 func mockStderr(t testing.TB) (read func() string, tearDown func()) {
 	reader, writer, err := os.Pipe()
@@ -154,296 +96,54 @@ func mockArgs(t testing.TB, arg ...string) func() {
 	}
 }
 
-func TestRealGit(t *testing.T) {
-	output, err := realGit("", "status")
-	assert.NoError(t, err)
-	t.Logf(output)
-
-	output, err = realGit("fake", "fail-cmd")
-	assert.Error(t, err)
-	t.Logf(output)
-}
-
-func TestMockedGit(t *testing.T) {
-	ctrl, tearDown := mockGit(t)
-	defer tearDown()
-
-	ctrl.EXPECT().
-		Git("").Return("test output", nil)
-	output, err := git("")
-	assert.NoError(t, err)
-	assert.Equal(t, "test output", output)
-}
-
-func TestDisabelGPG(t *testing.T) {
-	ctrl, tearDown := mockGit(t)
-	defer tearDown()
-	ctrl.EXPECT().
-		Git("", "config", "--local", "--get", "log.showSignature").
-		Return("true", nil)
-	ctrl.EXPECT().
-		Git("", "config", "--local", "log.showSignature", "false").
-		Return("", nil)
-	output, err := disableGPG()
-	assert.NoError(t, err)
-	assert.Equal(t, "true", output)
-
-	ctrl.EXPECT().
-		Git("", "config", "--local", "--get", "log.showSignature").
-		Return("", errors.New("error 1"))
-	ctrl.EXPECT().
-		Git("", "config", "--local", "log.showSignature", "false").
-		Return("", errors.New("error 2"))
-	output, err = disableGPG()
-	assert.Error(t, err)
-	assert.Equal(t, "", output)
-	assert.Equal(t, "error 2", err.Error())
-}
-
-func TestRestoreGPG(t *testing.T) {
-	ctrl, tearDown := mockGit(t)
+func TestChangeLogInput(t *testing.T) {
+	tearDown := mockStdin(t, "test-stdin-changelog")
 	defer tearDown()
-	ctrl.EXPECT().
-		Git("", "config", "--local", "log.showSignature", "test-value").
-		Return("true", nil)
-	err := restoreGPG("test-value")
-	assert.NoError(t, err)
-
-	ctrl.EXPECT().
-		Git("", "config", "--local", "--unset", "log.showSignature").
-		Return("", nil)
-	err = restoreGPG("")
+	input := changeLogInput()
+	assert.NotNil(t, input)
+	output, err := ioutil.ReadAll(input)
 	assert.NoError(t, err)
+	assert.Equal(t, "test-stdin-changelog", string(output))
 }
 
-func TestSetUpGPG(t *testing.T) {
-	ctrl, tearDown := mockGit(t)
-	defer tearDown()
-
-	if os.Getenv("GO_TEST_SETUPGPG") == "1" {
-		ctrl.EXPECT().
-			Git("", "config", "--local", "--get", "log.showSignature").
-			Return("test-value-signal", nil)
-		ctrl.EXPECT().
-			Git("", "config", "--local", "log.showSignature", "false").
-			Return("", nil)
-		_, err := setUpGPG()
+func TestSetUp(t *testing.T) {
+	if os.Getenv("GO_TEST_SETUP") == "1" {
+		_ = prepareGit(t)
+		_, err := git("", "config", "--local", "log.showSignature", "test-value-signal")
 		assert.NoError(t, err)
-
-		ctrl.EXPECT().
-			Git("", "config", "--local", "log.showSignature", "test-value-signal").
-			Return("true", nil)
-		err = syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+		repo, err := bumptag.OpenRepo(".", bumptag.BackendCLI)
+		assert.NoError(t, err)
+		_, err = setUp(repo)
 		assert.NoError(t, err)
-		time.Sleep(1 * time.Millisecond)
+		output, err := git("", "config", "--local", "--get", "log.showSignature")
+		assert.NoError(t, err)
+		assert.Equal(t, "false", output)
+
+		assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGINT))
+		time.Sleep(time.Second)
 		t.FailNow()
 	}
 
-	// Check failing at disabling the log.showSignature
-	ctrl.EXPECT().
-		Git("", "config", "--local", "--get", "log.showSignature").
-		Return("", nil)
-	ctrl.EXPECT().
-		Git("", "config", "--local", "log.showSignature", "false").
-		Return("", errors.New("test-error"))
-	_, err := setUpGPG()
-	assert.Error(t, err)
-
-	// Check right behavior
-	ctrl.EXPECT().
-		Git("", "config", "--local", "--get", "log.showSignature").
-		Return("test-value", nil)
-	ctrl.EXPECT().
-		Git("", "config", "--local", "log.showSignature", "false").
-		Return("", nil)
-	tearDownGPG, err := setUpGPG()
+	_ = prepareGit(t)
+	repo, err := bumptag.OpenRepo(".", bumptag.BackendCLI)
 	assert.NoError(t, err)
-
-	ctrl.EXPECT().
-		Git("", "config", "--local", "log.showSignature", "test-value").
-		Return("true", nil)
-	tearDownGPG()
+	tearDown, err := setUp(repo)
+	assert.NoError(t, err)
+	tearDown()
+	_, err = git("", "config", "--local", "--get", "log.showSignature")
+	assert.Error(t, err)
 
 	// Check restoring at signal
-	cmd := exec.Command(os.Args[0], "-test.run=TestSetUpGPG", "-test.v")
-	cmd.Env = append(os.Environ(), "GO_TEST_SETUPGPG=1")
+	cmd := exec.Command(os.Args[0], "-test.run=TestSetUp$", "-test.v")
+	cmd.Env = append(os.Environ(), "GO_TEST_SETUP=1")
 	output, err := cmd.CombinedOutput()
 	t.Log(string(output))
-	if e, ok := err.(*exec.ExitError); ok && e.ProcessState.ExitCode() == 42 {
+	if e, ok := err.(*exec.ExitError); ok && e.ProcessState.ExitCode() == exitInterrupted {
 		return
 	}
 	assert.NoError(t, err)
 }
 
-func TestGitConfig(t *testing.T) {
-	ctrl, tearDown := mockGit(t)
-	defer tearDown()
-
-	ctrl.EXPECT().
-		Git("", "config", "--get", "test-name").
-		Return("test-value", nil)
-	output := gitConfig("test-name", "test-default-value")
-	assert.Equal(t, "test-value", output)
-
-	ctrl.EXPECT().
-		Git("", "config", "--get", "test-name").
-		Return("", errors.New("test-random-error"))
-	output = gitConfig("test-name", "test-default-value")
-	assert.Equal(t, "test-default-value", output)
-}
-
-func TestGitConfigBool(t *testing.T) {
-	ctrl, tearDown := mockGit(t)
-	defer tearDown()
-
-	ctrl.EXPECT().
-		Git("", "config", "--get", "test-name").
-		Return("false", nil)
-	output := gitConfigBool("test-name", true)
-	assert.Equal(t, false, output)
-
-	ctrl.EXPECT().
-		Git("", "config", "--get", "test-name").
-		Return("test-string", nil)
-	output = gitConfigBool("test-name", true)
-	assert.Equal(t, true, output)
-}
-
-func TestFindTag(t *testing.T) {
-	ctrl, tearDown := mockGit(t)
-	defer tearDown()
-
-	ctrl.EXPECT().
-		Git("", "tag").Return("", errors.New("test-error"))
-	_, _, err := findTag()
-	assert.Error(t, err, "test-error")
-
-	ctrl.EXPECT().
-		Git("", "tag").Return("", nil)
-	tag, tagName, err := findTag()
-	assert.NoError(t, err)
-	assert.Equal(t, "", tagName)
-	assert.Equal(t, "0.0.0", tag.String())
-
-	tagCall := ctrl.EXPECT().
-		Git("", "tag").Return("text-tag", nil)
-	ctrl.EXPECT().
-		Git("", "describe", "--tags", "--abbrev=0").
-		Return("", errors.New("test-error")).After(tagCall)
-	_, _, err = findTag()
-	assert.Error(t, err, "test-error")
-
-	tagCall = ctrl.EXPECT().
-		Git("", "tag").Return("text-tag", nil)
-	ctrl.EXPECT().
-		Git("", "describe", "--tags", "--abbrev=0").
-		Return("1.2.3", nil).After(tagCall)
-	tag, tagName, err = findTag()
-	assert.NoError(t, err)
-	assert.Equal(t, "1.2.3", tagName)
-	assert.Equal(t, "1.2.3", tag.String())
-
-	tagCall = ctrl.EXPECT().
-		Git("", "tag").Return("text-tag", nil)
-	ctrl.EXPECT().
-		Git("", "describe", "--tags", "--abbrev=0").
-		Return("text-tag", nil).After(tagCall)
-	_, _, err = findTag()
-	assert.Error(t, err)
-
-	tagPrefix = "v"
-	tagCall = ctrl.EXPECT().
-		Git("", "tag").Return("text-tag", nil)
-	ctrl.EXPECT().
-		Git("", "describe", "--tags", "--abbrev=0").
-		Return("v-text-tag", nil).After(tagCall)
-	_, _, err = findTag()
-	assert.Error(t, err)
-}
-
-func TestCreateTag(t *testing.T) {
-	ctrl, tearDown := mockGit(t)
-	defer tearDown()
-
-	ctrl.EXPECT().
-		Git("test-annotation", "tag", "-F-", "test-tag").
-		Return("", nil)
-	err := createTag("test-tag", "test-annotation", false)
-	assert.NoError(t, err)
-
-	ctrl.EXPECT().
-		Git("test-annotation", "tag", "-F-", "--sign", "test-tag").
-		Return("", nil)
-	err = createTag("test-tag", "test-annotation", true)
-	assert.NoError(t, err)
-
-	ctrl.EXPECT().
-		Git("test-annotation", "tag", "-F-", "--sign", "test-tag").
-		Return("", errors.New("test-error"))
-	err = createTag("test-tag", "test-annotation", true)
-	assert.Error(t, err)
-	assert.Equal(t, "test-error", err.Error())
-}
-
-func TestShowTag(t *testing.T) {
-	ctrl, tearDown := mockGit(t)
-	defer tearDown()
-
-	ctrl.EXPECT().
-		Git("", "show", "test-tag").
-		Return("test-output", nil)
-	output, err := showTag("test-tag")
-	assert.NoError(t, err)
-	assert.Equal(t, "test-output", output)
-
-	ctrl.EXPECT().
-		Git("", "show", "test-tag").
-		Return("", errors.New("test-error"))
-	_, err = showTag("test-tag")
-	assert.Error(t, err)
-	assert.Equal(t, "test-error", err.Error())
-}
-
-func TestGetChangeLogStdin(t *testing.T) {
-	tearDown := mockStdin(t, "test-stdin-changelog")
-	defer tearDown()
-	output, err := getChangeLog("test-tag")
-	assert.NoError(t, err)
-	assert.Equal(t, "test-stdin-changelog", output)
-}
-
-func TestGetChangeLogGitCommits(t *testing.T) {
-	ctrl, tearDown := mockGit(t)
-	defer tearDown()
-
-	ctrl.EXPECT().
-		Git("", "log", "--pretty=%h %s", "--no-merges", "test-tag..HEAD").
-		Return("test-output", nil)
-	output, err := getChangeLog("test-tag")
-	assert.NoError(t, err)
-	assert.Equal(t, "* test-output", output)
-
-	ctrl.EXPECT().
-		Git("", "log", "--pretty=%h %s", "--no-merges").
-		Return("test-output", nil)
-	output, err = getChangeLog("")
-	assert.NoError(t, err)
-	assert.Equal(t, "* test-output", output)
-
-	ctrl.EXPECT().
-		Git("", "log", "--pretty=%h %s", "--no-merges").
-		Return("", errors.New("test-error"))
-	_, err = getChangeLog("")
-	assert.Error(t, err)
-	assert.Equal(t, "test-error", err.Error())
-}
-
-func TestMakeAnnotation(t *testing.T) {
-	output := makeAnnotation("test-changelog", "test-tag")
-	assert.Contains(t, output, "test-changelog")
-	assert.Contains(t, output, "test-tag")
-}
-
 func TestUsage(t *testing.T) {
 	read, tearDown := mockStdout(t)
 	defer tearDown()
@@ -477,70 +177,26 @@ func TestCreateFlag(t *testing.T) {
 	assert.Equal(t, 1, cnt)
 }
 
-func TestGetRemote(t *testing.T) {
-	ctrl, tearDown := mockGit(t)
-	defer tearDown()
-
-	ctrl.EXPECT().
-		Git("", "branch", "--list", "-vv").
-		Return("", errors.New("test-error"))
-	output, err := getRemote()
-	assert.Error(t, err)
-	assert.Empty(t, output)
-
-	ctrl.EXPECT().
-		Git("", "branch", "--list", "-vv").
-		Return("", nil)
-	output, err = getRemote()
-	assert.NoError(t, err)
-	assert.Equal(t, defaultRemote, output)
-
-	ctrl.EXPECT().
-		Git("", "branch", "--list", "-vv").
-		Return(`
-  master       cc51028 [origin/master] Merge pull request #6 from SVilgelm/tests
-* new_features b2fedca Add silent mode`, nil)
-	output, err = getRemote()
-	assert.Error(t, err)
-	assert.Empty(t, output)
-
-	ctrl.EXPECT().
-		Git("", "branch", "--list", "-vv").
-		Return(`
-  master       cc51028 [origin/master] Merge pull request #6 from SVilgelm/tests
-* new_features b2fedca [Add silent mode]`, nil)
-	output, err = getRemote()
-	assert.Error(t, err)
-	assert.Empty(t, output)
-
-	ctrl.EXPECT().
-		Git("", "branch", "--list", "-vv").
-		Return(`
-* master       cc51028 [test-origin/master] Merge pull request #6 from SVilgelm/tests
-  new_features b2fedca [Add silent mode]`, nil)
-	output, err = getRemote()
-	assert.NoError(t, err)
-	assert.Equal(t, "test-origin", output)
-}
-
-func TestPushTag(t *testing.T) {
-	ctrl, tearDown := mockGit(t)
-	defer tearDown()
-
-	ctrl.EXPECT().
-		Git("", "push", "test-remote", "test-tag").
-		Return("", errors.New("test-error"))
-	err := pushTag("test-remote", "test-tag")
-	assert.EqualError(t, err, "test-error")
-}
-
 // Scenarios
 
+// execMain runs main and fails the test if it exits with an error.
 func execMain(t testing.TB, arg ...string) (stdout, stderr string) {
+	stdout, stderr, code := execMainCode(t, arg...)
+	assert.Equal(t, exitOK, code, stderr)
+	return stdout, stderr
+}
+
+// execMainCode runs main and returns its output and the exit code.
+func execMainCode(t testing.TB, arg ...string) (stdout, stderr string, code int) {
 	realCommandLine := flag.CommandLine
+	realExit := exit
 	defer func() {
 		flag.CommandLine = realCommandLine
+		exit = realExit
 	}()
+	exit = func(c int) {
+		code = c
+	}
 	flag.CommandLine = flag.NewFlagSet("test-flag-set", flag.ContinueOnError)
 	tearDownArgs := mockArgs(t, arg...)
 	defer tearDownArgs()
@@ -549,66 +205,58 @@ func execMain(t testing.TB, arg ...string) (stdout, stderr string) {
 	readStderr, tearDownStderr := mockStderr(t)
 	defer tearDownStderr()
 	main()
-	return readStdout(), readStderr()
+	return readStdout(), readStderr(), code
 }
 
-func prepareGit(t testing.TB) (func(), func()) {
-	dir, err := ioutil.TempDir("", "bumptag")
-	assert.NoError(t, err)
-	t.Logf("Dir: %s", dir)
-	remoteDir, err := ioutil.TempDir("", "bumptag")
-	assert.NoError(t, err)
-	t.Logf("Remote dir: %s", dir)
-	newGit := func(input string, arg ...string) (string, error) {
-		msg := fmt.Sprintf("git %s", strings.Join(arg, " "))
-		if len(input) > 0 {
-			msg += "\nInput: " + input
-		}
-		t.Log(msg)
-		cmd := exec.Command("git", arg...)
-		cmd.Dir = dir
-		if len(input) > 0 {
-			cmd.Stdin = strings.NewReader(input)
-		}
-		var stdout bytes.Buffer
-		cmd.Stdout = &stdout
-
-		var stderr bytes.Buffer
-		cmd.Stderr = &stderr
-
-		err := cmd.Run()
-		if err != nil {
-			text := fmt.Sprintf(
-				"Command '%s' failed: %s",
-				strings.Join(cmd.Args, " "),
-				err.Error(),
-			)
-			errText := strings.TrimSpace(stderr.String())
-			if len(errText) > 0 {
-				text += "\n" + errText
-			}
-			err = errors.New(text)
-		}
-		output := strings.TrimSpace(stdout.String())
-		t.Logf("Output: %s", output)
-		if err != nil {
-			t.Logf("Error: %s", err.Error())
+// git runs the git binary in the current directory.
+func git(input string, arg ...string) (string, error) {
+	cmd := exec.Command("git", arg...)
+	if len(input) > 0 {
+		cmd.Stdin = strings.NewReader(input)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		text := fmt.Sprintf(
+			"Command '%s' failed: %s",
+			strings.Join(cmd.Args, " "),
+			err.Error(),
+		)
+		errText := strings.TrimSpace(stderr.String())
+		if len(errText) > 0 {
+			text += "\n" + errText
 		}
-		return output, err
+		err = errors.New(text)
 	}
+	return strings.TrimSpace(stdout.String()), err
+}
+
+// prepareGit creates a repository with one commit pushed to the remote and changes the current directory to it.
+func prepareGit(t *testing.T) func() {
+	dir := t.TempDir()
+	t.Logf("Dir: %s", dir)
+	remoteDir := t.TempDir()
+	t.Logf("Remote dir: %s", remoteDir)
 
 	cmd := exec.Command("git", "init", "--bare")
 	cmd.Dir = remoteDir
-	err = cmd.Run()
+	err := cmd.Run()
 	assert.NoError(t, err)
 
-	git = newGit
+	t.Chdir(dir)
 	_, err = git("", "init")
 	assert.NoError(t, err)
 	_, err = git("", "remote", "add", "origin", remoteDir)
 	assert.NoError(t, err)
 	_, err = git("", "config", "--local", "commit.gpgsign", "false")
 	assert.NoError(t, err)
+	_, err = git("", "config", "--local", "tag.gpgSign", "false")
+	assert.NoError(t, err)
 	_, err = git("", "config", "--local", "user.email", "test@example.com")
 	assert.NoError(t, err)
 	_, err = git("", "config", "--local", "user.name", "Test Example")
@@ -638,16 +286,21 @@ func prepareGit(t testing.TB) (func(), func()) {
 	prepareCommit()
 	_, err = git("", "push", "--set-upstream", "origin", "master")
 	assert.NoError(t, err)
+	return prepareCommit
+}
 
-	return prepareCommit, func() {
-		git = realGit
-		t.Logf("Remove dir: %s", dir)
-		err := os.RemoveAll(dir)
-		assert.NoError(t, err)
-		t.Logf("Remove remote dir: %s", remoteDir)
-		err = os.RemoveAll(remoteDir)
-		assert.NoError(t, err)
-	}
+func prepareModule(t testing.TB, path string) string {
+	root, err := git("", "rev-parse", "--show-toplevel")
+	assert.NoError(t, err)
+	dir := filepath.Join(root, filepath.FromSlash(path))
+	assert.NoError(t, os.MkdirAll(dir, 0o755))
+	content := "module " + filepath.ToSlash(filepath.Join("example.com/test", path)) + "\n\ngo 1.17\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(content), 0o600))
+	_, err = git("", "add", filepath.Join(path, "go.mod"))
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "Add module "+path)
+	assert.NoError(t, err)
+	return dir
 }
 
 func TestMainVersion(t *testing.T) {
@@ -661,11 +314,9 @@ func TestMainVersion(t *testing.T) {
 }
 
 func TestMainFindTag(t *testing.T) {
-	_, tearDown := prepareGit(t)
-	defer tearDown()
+	_ = prepareGit(t)
 	stdout, _ := execMain(t, "--find-tag")
 	assert.Empty(t, stdout)
-	tagPrefix = "v"
 	_, err := git("", "tag", "v1.0.1")
 	assert.NoError(t, err)
 	stdout, _ = execMain(t, "--find-tag")
@@ -673,16 +324,14 @@ func TestMainFindTag(t *testing.T) {
 }
 
 func TestMainDryRun(t *testing.T) {
-	_, tearDown := prepareGit(t)
-	defer tearDown()
+	_ = prepareGit(t)
 	stdout, _ := execMain(t, "--dry-run")
 	assert.Contains(t, stdout, "commit-#0")
 	assert.Contains(t, stdout, "v0.1.0")
 }
 
 func TestMainTagAuto(t *testing.T) {
-	_, tearDown := prepareGit(t)
-	defer tearDown()
+	_ = prepareGit(t)
 	stdout, _ := execMain(t)
 	assert.Contains(t, stdout, "commit-#0")
 	assert.Contains(t, stdout, "v0.1.0")
@@ -692,8 +341,7 @@ func TestMainTagAuto(t *testing.T) {
 }
 
 func TestMainTagSilent(t *testing.T) {
-	_, tearDown := prepareGit(t)
-	defer tearDown()
+	_ = prepareGit(t)
 	stdout, _ := execMain(t, "--silent")
 	assert.Empty(t, stdout)
 	output, err := git("", "tag", "--list")
@@ -702,8 +350,7 @@ func TestMainTagSilent(t *testing.T) {
 }
 
 func TestMainTagSpecified(t *testing.T) {
-	_, tearDown := prepareGit(t)
-	defer tearDown()
+	_ = prepareGit(t)
 	_, _ = execMain(t, "v3.0.3")
 	output, err := git("", "tag", "--list")
 	assert.NoError(t, err)
@@ -711,8 +358,7 @@ func TestMainTagSpecified(t *testing.T) {
 }
 
 func TestMainTagMajor(t *testing.T) {
-	prepareCommit, tearDown := prepareGit(t)
-	defer tearDown()
+	prepareCommit := prepareGit(t)
 	_, err := git("", "tag", "v1.1.1")
 	assert.NoError(t, err)
 	prepareCommit()
@@ -723,8 +369,7 @@ func TestMainTagMajor(t *testing.T) {
 }
 
 func TestMainTagMinor(t *testing.T) {
-	prepareCommit, tearDown := prepareGit(t)
-	defer tearDown()
+	prepareCommit := prepareGit(t)
 	_, err := git("", "tag", "v1.1.1")
 	assert.NoError(t, err)
 	prepareCommit()
@@ -735,8 +380,7 @@ func TestMainTagMinor(t *testing.T) {
 }
 
 func TestMainTagPatch(t *testing.T) {
-	prepareCommit, tearDown := prepareGit(t)
-	defer tearDown()
+	prepareCommit := prepareGit(t)
 	_, err := git("", "tag", "v1.1.1")
 	assert.NoError(t, err)
 	prepareCommit()
@@ -747,19 +391,45 @@ func TestMainTagPatch(t *testing.T) {
 }
 
 func TestMainTagSpecifiedWrong(t *testing.T) {
-	_, tearDown := prepareGit(t)
-	defer tearDown()
-	assert.Panics(t, func() {
-		_, _ = execMain(t, "v3.0")
-	})
+	_ = prepareGit(t)
+	_, _, code := execMainCode(t, "v3.0")
+	assert.Equal(t, exitInvalidVersion, code)
 	output, err := git("", "tag", "--list")
 	assert.NoError(t, err)
 	assert.NotContains(t, output, "v3.0")
 }
 
+func TestMainInvalidFlags(t *testing.T) {
+	_ = prepareGit(t)
+	for _, tc := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--output", "xml"}, "unknown output format 'xml'"},
+		{[]string{"--strategy", "bogus"}, "unknown strategy 'bogus'"},
+		{[]string{"--backend", "bogus"}, "unknown backend 'bogus'"},
+		{[]string{"--skip-check", "clean,bogus"}, "unknown check 'bogus'"},
+		{[]string{"--release", "bogus"}, "unknown forge 'bogus'"},
+		{[]string{"--release", "github"}, "--release requires --auto-push"},
+		{[]string{"--pre", "a.b"}, "invalid pre-release channel 'a.b'"},
+		{[]string{"--check"}, "--check requires --bump-files or the 'replace' setting"},
+		{[]string{"--all-modules", "--module", "x"}, "the tag name and --module cannot be used with --all-modules"},
+		{[]string{"--all-modules", "v1.0.0"}, "the tag name and --module cannot be used with --all-modules"},
+		{[]string{"suggest", "--all-modules"}, "the suggest command cannot be used with --all-modules"},
+		{[]string{"--all-modules", "--ci"}, "--ci cannot be used with --all-modules"},
+		{[]string{"--all-modules", "--check"}, "--check cannot be used with --all-modules"},
+	} {
+		_, stderr, code := execMainCode(t, tc.args...)
+		assert.Equal(t, exitInvalidFlags, code, tc.args)
+		assert.Equal(t, "Error: "+tc.expected+"\n", stderr, tc.args)
+	}
+	output, err := git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+}
+
 func TestMainTagAutoPush(t *testing.T) {
-	_, tearDown := prepareGit(t)
-	defer tearDown()
+	_ = prepareGit(t)
 	stdout, _ := execMain(t, "--auto-push")
 	assert.Contains(t, stdout, "pushed")
 	output, err := git("", "tag", "--list")
@@ -771,8 +441,7 @@ func TestMainTagAutoPush(t *testing.T) {
 }
 
 func TestMainTagBranch(t *testing.T) {
-	prepareCommit, tearDown := prepareGit(t)
-	defer tearDown()
+	prepareCommit := prepareGit(t)
 
 	_, err := git("", "tag", "v1.0.0")
 	assert.NoError(t, err)
@@ -796,18 +465,195 @@ func TestMainTagBranch(t *testing.T) {
 	assert.NotContains(t, output, "v2.0.0")
 }
 
-func TestPanicIfError(t *testing.T) {
-	panicIfError(nil)
+func TestMainErrors(t *testing.T) {
+	_ = prepareGit(t)
+	_, err := git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+
+	_, stderr, code := execMainCode(t, "v1.0.0")
+	assert.Equal(t, exitTagExists, code)
+	assert.Equal(t, "Error: the tag check failed: the tag already exists: 'v1.0.0'\n", stderr)
+
+	_, stderr, code = execMainCode(t, "--remote", "upstream", "-a", "v1.1.0")
+	assert.Equal(t, exitNoRemote, code)
+	assert.Equal(t, "Error: the tag check failed: no remote: the remote 'upstream' is not configured\n", stderr)
+	output, err := git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0", output, "the missing remote is found before the tag is created")
+	_, _, code = execMainCode(t, "--remote", "upstream", "v1.1.0")
+	assert.Equal(t, exitOK, code, "the remote is not required without --auto-push")
+	_, err = git("", "tag", "--delete", "v1.1.0")
+	assert.NoError(t, err)
+
+	_, err = git("", "remote", "set-url", "origin", filepath.Join(t.TempDir(), "missing"))
+	assert.NoError(t, err)
+	_, stderr, code = execMainCode(t, "--debug", "--backend", "cli", "--skip-check", "behind,tag", "-a", "v1.2.0")
+	assert.Equal(t, exitGitFailed, code)
+	assert.Contains(t, stderr, "+ git push origin v1.2.0\nexit status 128\nfatal: ")
+	assert.Contains(t, stderr, "Error: command 'git push origin v1.2.0' failed: exit status 128: fatal: ")
+}
+
+func TestMainRollback(t *testing.T) {
+	for _, backend := range []string{bumptag.BackendCLI, bumptag.BackendGo} {
+		t.Run(backend, func(t *testing.T) {
+			_ = prepareGit(t)
+			head, err := git("", "rev-parse", "HEAD")
+			assert.NoError(t, err)
+			_, err = git("", "remote", "set-url", "origin", filepath.Join(t.TempDir(), "missing"))
+			assert.NoError(t, err)
 
-	defer func() {
-		assert.NotNil(t, recover())
-	}()
-	panicIfError(errors.New("fake error"))
+			_, stderr, code := execMainCode(
+				t, "--backend", backend, "--changelog-file", "CHANGELOG.md", "--skip-check", "behind,tag", "-a", "v1.0.0",
+			)
+			assert.NotEqual(t, exitOK, code)
+			assert.Contains(t, stderr, "Rolled back the tag 'v1.0.0'\n"+
+				"Rolled back the commit 'Update CHANGELOG.md for v1.0.0'\n"+
+				"Rolled back the changes of 'CHANGELOG.md'\n"+
+				"Error: ")
+
+			output, err := git("", "tag", "--list")
+			assert.NoError(t, err)
+			assert.Empty(t, output)
+			output, err = git("", "rev-parse", "HEAD")
+			assert.NoError(t, err)
+			assert.Equal(t, head, output)
+			output, err = git("", "status", "--porcelain")
+			assert.NoError(t, err)
+			assert.Empty(t, output)
+			assert.NoFileExists(t, "CHANGELOG.md")
+		})
+	}
+}
+
+func TestMainBumpFiles(t *testing.T) {
+	_ = prepareGit(t)
+	assert.NoError(t, os.WriteFile("main.go", []byte("package main\n\nvar version = \"0.0.0\"\n"), 0o600))
+	assert.NoError(t, os.WriteFile("package.json", []byte("{\n  \"version\": \"0.0.0\"\n}\n"), 0o600))
+	_, err := git("", "add", "main.go", "package.json")
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "Add the version files")
+	assert.NoError(t, err)
+
+	_, _ = execMain(t, "--bump-files", "main.go,package.json", "--bump-message", "Release {{.TagName}}", "v1.2.0")
+	output, err := git("", "log", "-1", "--pretty=%s%d")
+	assert.NoError(t, err)
+	assert.Equal(t, "Release v1.2.0 (HEAD -> master, tag: v1.2.0)", output)
+	data, err := os.ReadFile("main.go")
+	assert.NoError(t, err)
+	assert.Equal(t, "package main\n\nvar version = \"1.2.0\"\n", string(data))
+	data, err = os.ReadFile("package.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"version\": \"1.2.0\"\n}\n", string(data))
+
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte("bump-files: main.go\n"), 0o600))
+	_, _ = execMain(t, "--patch")
+	output, err = git("", "log", "-1", "--pretty=%s%d")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bump version to v1.2.1 (HEAD -> master, tag: v1.2.1)", output)
+}
+
+func TestMainCheck(t *testing.T) {
+	_ = prepareGit(t)
+	_, _, code := execMainCode(t, "--check")
+	assert.Equal(t, exitInvalidFlags, code)
+
+	assert.NoError(t, os.WriteFile("Dockerfile", []byte("FROM alpine\nARG VERSION=0.0.0\n"), 0o600))
+	assert.NoError(t, os.WriteFile(".bumptag.yaml", []byte(`replace:
+  - file: Dockerfile
+    pattern: '(ARG VERSION=)\S+'
+    replacement: '${1}{{.Version}}'
+`), 0o600))
+	_, err := git("", "add", "Dockerfile", ".bumptag.yaml")
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "Add Dockerfile")
+	assert.NoError(t, err)
+
+	_, stderr, code := execMainCode(t, "--check")
+	assert.Equal(t, exitFailure, code)
+	assert.Equal(t, "Error: no tag to check the files against\n", stderr)
+
+	_, _ = execMain(t, "v1.2.0")
+	data, err := os.ReadFile("Dockerfile")
+	assert.NoError(t, err)
+	assert.Equal(t, "FROM alpine\nARG VERSION=1.2.0\n", string(data))
+	stdout, _ := execMain(t, "--check")
+	assert.Equal(t, "The files match the tag 'v1.2.0'\n", stdout)
+
+	assert.NoError(t, os.WriteFile("Dockerfile", []byte("FROM alpine\nARG VERSION=1.1.0\n"), 0o600))
+	stdout, stderr, code = execMainCode(t, "--check", "--output", "json")
+	assert.Equal(t, exitCheckFailed, code)
+	assert.JSONEq(t, `{"module": ".", "tag": "v1.2.0", "outdated": ["Dockerfile"]}`, stdout)
+	assert.Equal(t, "Error: the check failed: the files do not match the tag 'v1.2.0': Dockerfile\n", stderr)
+}
+
+func TestMainHooks(t *testing.T) {
+	_ = prepareGit(t)
+	t.Setenv("BUMPTAG_PRE_TAG", "exit 1")
+	_, stderr, code := execMainCode(t, "v1.0.0")
+	assert.Equal(t, exitFailure, code)
+	assert.Equal(t, "Error: the pre-tag hook failed: exit status 1\n", stderr)
+	output, err := git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+
+	t.Setenv("BUMPTAG_PRE_TAG", "")
+	t.Setenv("BUMPTAG_POST_TAG", `echo "created $BUMPTAG_NEW_TAG"`)
+	stdout, stderr := execMain(t, "-s", "v1.0.0")
+	assert.Empty(t, stdout, "the output of the hooks is printed to stderr")
+	assert.Equal(t, "created v1.0.0\n", stderr)
+}
+
+func TestMainSign(t *testing.T) {
+	_ = prepareGit(t)
+	keys := t.TempDir()
+	for _, name := range []string{"signing", "other"} {
+		cmd := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", name, "-f", filepath.Join(keys, name))
+		if err := cmd.Run(); err != nil {
+			t.Skipf("ssh-keygen is not available: %s", err)
+		}
+	}
+	other, err := os.ReadFile(filepath.Join(keys, "other.pub"))
+	assert.NoError(t, err)
+	allowedSigners := filepath.Join(keys, "allowed_signers")
+	assert.NoError(t, os.WriteFile(allowedSigners, append([]byte("test@example.com "), other...), 0o600))
+	for _, args := range [][]string{
+		{"gpg.format", "ssh"},
+		{"gpg.ssh.allowedSignersFile", allowedSigners},
+		{"user.signingkey", filepath.Join(keys, "other.pub")},
+	} {
+		_, err := git("", append([]string{"config", "--local"}, args...)...)
+		assert.NoError(t, err)
+	}
+
+	_, stderr, code := execMainCode(t, "--no-sign", "--sign", "v1.0.0")
+	assert.Equal(t, exitInvalidFlags, code)
+	assert.Equal(t, "Error: --no-sign cannot be used with --sign or --sign-key\n", stderr)
+
+	_, stderr, code = execMainCode(t, "--sign-key", filepath.Join(keys, "signing.pub"), "v1.0.0")
+	assert.Equal(t, exitGitFailed, code)
+	assert.Equal(
+		t,
+		"Rolled back the tag 'v1.0.0'\nError: the signature of the tag 'v1.0.0' is bad: "+
+			"command 'git tag --verify v1.0.0' failed: exit status 1: No principal matched.\n",
+		stderr,
+	)
+	output, err := git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+
+	_, _ = execMain(t, "-s", "--sign", "v1.0.0")
+	_, err = git("", "tag", "--verify", "v1.0.0")
+	assert.NoError(t, err, "the tag is signed by user.signingkey")
+
+	_, err = git("", "config", "--local", "tag.gpgSign", "true")
+	assert.NoError(t, err)
+	_, _ = execMain(t, "-s", "--no-sign", "v1.1.0")
+	_, err = git("", "tag", "--verify", "v1.1.0")
+	assert.Error(t, err, "the tag is not signed")
 }
 
 func TestMainEdit(t *testing.T) {
-	prepareCommit, tearDown := prepareGit(t)
-	defer tearDown()
+	prepareCommit := prepareGit(t)
 	_, err := git("", "tag", "v1.1.1")
 	assert.NoError(t, err)
 	prepareCommit()
@@ -817,3 +663,644 @@ func TestMainEdit(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, output, "v1.2.0")
 }
+
+func TestMainAutoBump(t *testing.T) {
+	prepareCommit := prepareGit(t)
+	_, err := git("", "tag", "v1.1.1")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, err = git("", "commit", "--allow-empty", "-m", "fix: test fix")
+	assert.NoError(t, err)
+	stdout, _ := execMain(t, "--auto", "--dry-run")
+	assert.Contains(t, stdout, "Bump PATCH version: bug fix in")
+	assert.Contains(t, stdout, "v1.1.2")
+
+	_, err = git("", "commit", "--allow-empty", "-m", "feat!: test breaking change")
+	assert.NoError(t, err)
+	stdout, _ = execMain(t, "--auto", "--dry-run")
+	assert.Contains(t, stdout, "Bump MAJOR version: breaking change in")
+	assert.Contains(t, stdout, "v2.0.0")
+
+	_, _ = execMain(t, "--auto", "--patch")
+	output, err := git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "v1.1.2")
+}
+
+func TestMainPreRelease(t *testing.T) {
+	prepareCommit := prepareGit(t)
+	_, err := git("", "tag", "v1.2.0")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, _ = execMain(t, "--silent", "--pre", "rc")
+	stdout, _ := execMain(t, "--find-tag")
+	assert.Equal(t, "v1.3.0-rc.1", stdout)
+
+	prepareCommit()
+	_, _ = execMain(t, "--silent", "--pre", "rc")
+	stdout, _ = execMain(t, "--find-tag")
+	assert.Equal(t, "v1.3.0-rc.2", stdout)
+
+	stdout, _ = execMain(t, "--dry-run")
+	assert.Contains(t, stdout, "Bump version v1.3.0\n", "the pre-release is finalised by a bump")
+	_, _ = execMain(t, "--silent", "--promote")
+	stdout, _ = execMain(t, "--find-tag")
+	assert.Equal(t, "v1.3.0", stdout)
+	output, err := git("", "tag", "--list", "--format=%(contents)", "v1.3.0")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "commit-#1", "the final release contains the commits of the pre-releases")
+	assert.Contains(t, output, "commit-#2")
+
+	_, _, code := execMainCode(t, "--promote")
+	assert.Equal(t, exitInvalidVersion, code)
+
+	prepareCommit()
+	_, _ = execMain(t, "--silent", "--patch", "--pre", "beta")
+	stdout, _ = execMain(t, "--find-tag")
+	assert.Equal(t, "v1.3.1-beta.1", stdout)
+}
+
+func TestMainMetadata(t *testing.T) {
+	prepareCommit := prepareGit(t)
+	t.Setenv("BUMPTAG_BUILD", "451")
+	_, err := git("", "tag", "v1.1.0+build.450")
+	assert.NoError(t, err)
+	prepareCommit()
+	sha, err := git("", "rev-parse", "--short", "HEAD")
+	assert.NoError(t, err)
+	_, _ = execMain(t, "--silent", "--metadata", "build.{build}.{sha}")
+	stdout, _ := execMain(t, "--find-tag")
+	assert.Equal(t, "v1.2.0+build.451."+sha, stdout)
+
+	_, _, code := execMainCode(t, "--metadata", "{unknown}")
+	assert.Equal(t, exitFailure, code)
+}
+
+func TestMainStrategy(t *testing.T) {
+	prepareCommit := prepareGit(t)
+
+	_, err := git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	_, err = git("", "checkout", "-b", "v2")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, err = git("", "tag", "v2.0.0")
+	assert.NoError(t, err)
+	_, err = git("", "checkout", "master")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, err = git("", "tag", "deploy-prod")
+	assert.NoError(t, err)
+
+	_, _, code := execMainCode(t, "--find-tag")
+	assert.Equal(t, exitInvalidVersion, code)
+
+	stdout, _ := execMain(t, "--find-tag", "--strategy", "highest-reachable")
+	assert.Equal(t, "v1.0.0", stdout)
+
+	stdout, stderr := execMain(t, "--find-tag", "--strategy", "highest")
+	assert.Equal(t, "v2.0.0", stdout)
+	assert.Empty(t, stderr)
+
+	_, err = git("", "tag", "vnext")
+	assert.NoError(t, err)
+	stdout, stderr = execMain(t, "--find-tag", "--strategy", "highest")
+	assert.Equal(t, "v2.0.0", stdout)
+	assert.True(t, strings.HasPrefix(stderr, "Warning: skipping the tag 'vnext': "), stderr)
+	_, stderr = execMain(t, "--find-tag", "--strategy", "highest", "--output", "json")
+	assert.Empty(t, stderr, "the warnings are not printed with the JSON output")
+
+	_, _ = execMain(t, "--silent", "--strategy", "highest")
+	output, err := git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "v2.1.0")
+}
+
+func TestMainModule(t *testing.T) {
+	prepareCommit := prepareGit(t)
+
+	_, err := git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	dir := prepareModule(t, "tools/foo")
+	_, err = git("", "tag", "tools/foo/v0.1.0")
+	assert.NoError(t, err)
+	prepareCommit()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo.go"), []byte("package foo\n"), 0o600))
+	_, err = git("", "add", filepath.Join("tools", "foo", "foo.go"))
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "Add foo.go")
+	assert.NoError(t, err)
+	prepareCommit()
+
+	stdout, _ := execMain(t, "--module", dir, "--find-tag")
+	assert.Equal(t, "tools/foo/v0.1.0", stdout)
+
+	stdout, _ = execMain(t, "--module", dir, "--dry-run")
+	assert.Contains(t, stdout, "tools/foo/v0.2.0")
+	assert.Contains(t, stdout, "Add foo.go")
+	assert.NotContains(t, stdout, "commit-#")
+
+	_, _ = execMain(t, "--module", dir, "--silent", "--patch")
+	output, err := git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "tools/foo/v0.1.1")
+
+	stdout, _ = execMain(t, "--find-tag")
+	assert.Equal(t, "v1.0.0", stdout)
+}
+
+func TestMainAllModules(t *testing.T) {
+	prepareCommit := prepareGit(t)
+
+	_ = prepareModule(t, ".")
+	_, err := git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	foo := prepareModule(t, "tools/foo")
+	_, err = git("", "tag", "tools/foo/v0.1.0")
+	assert.NoError(t, err)
+	_ = prepareModule(t, "tools/bar")
+	_, err = git("", "tag", "tools/bar/v2.0.0")
+	assert.NoError(t, err)
+
+	prepareCommit()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(foo, "foo.go"), []byte("package foo\n"), 0o600))
+	_, err = git("", "add", filepath.Join("tools", "foo", "foo.go"))
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "feat: add foo.go")
+	assert.NoError(t, err)
+
+	stdout, _ := execMain(t, "--all-modules", "--find-tag")
+	assert.Contains(t, stdout, ".: v1.0.0\n")
+	assert.Contains(t, stdout, "tools/foo: tools/foo/v0.1.0\n")
+	assert.Contains(t, stdout, "tools/bar: tools/bar/v2.0.0\n")
+
+	stdout, _ = execMain(t, "--all-modules", "--auto", "--dry-run")
+	assert.Contains(t, stdout, "Module '.': v1.0.0 -> v1.0.1")
+	assert.Contains(t, stdout, "Module 'tools/foo': tools/foo/v0.1.0 -> tools/foo/v0.2.0")
+	assert.Contains(t, stdout, "Module 'tools/bar': no changes since 'tools/bar/v2.0.0'")
+
+	_, _ = execMain(t, "--all-modules", "--auto", "--silent")
+	output, err := git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "v1.0.1")
+	assert.Contains(t, output, "tools/foo/v0.2.0")
+	assert.NotContains(t, output, "tools/bar/v2.1.0")
+	output, err = git("", "tag", "-n9", "v1.0.1")
+	assert.NoError(t, err)
+	assert.NotContains(t, output, "add foo.go")
+
+	_, _, code := execMainCode(t, "--all-modules", "v3.0.0")
+	assert.Equal(t, exitInvalidFlags, code)
+}
+
+func TestMainFixModulePath(t *testing.T) {
+	_ = prepareGit(t)
+
+	dir := prepareModule(t, "tools/foo")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "bar"), 0o755))
+	assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(dir, "main.go"),
+		[]byte("package main\n\nimport _ \"example.com/test/tools/foo/bar\"\n"),
+		0o600,
+	))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bar", "bar.go"), []byte("package bar\n"), 0o600))
+	_, err := git("", "add", "tools")
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "Add main.go")
+	assert.NoError(t, err)
+	_, err = git("", "tag", "tools/foo/v1.0.0")
+	assert.NoError(t, err)
+
+	_, _, code := execMainCode(t, "--module", dir, "--major", "--dry-run")
+	assert.Equal(t, exitInvalidVersion, code, "the dry run reports the mismatch too")
+	bar := filepath.Join(dir, "bar", "bar.go")
+	assert.NoError(t, ioutil.WriteFile(bar, []byte("// Package bar\npackage bar\n"), 0o600))
+	_, err = git("", "commit", "-am", "Document bar")
+	assert.NoError(t, err)
+	_, _, code = execMainCode(t, "--all-modules", "--major", "--dry-run")
+	assert.Equal(t, exitInvalidVersion, code)
+	stdout, _ := execMain(t, "--module", dir, "--major", "--dry-run", "--fix-module-path")
+	assert.Contains(t, stdout, "tools/foo/v2.0.0")
+	_, _, code = execMainCode(t, "--module", dir, "--major")
+	assert.Equal(t, exitInvalidVersion, code)
+	output, err := git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.NotContains(t, output, "tools/foo/v2.0.0")
+
+	_, _ = execMain(t, "--module", dir, "--major", "--silent", "--fix-module-path")
+	output, err = git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "tools/foo/v2.0.0")
+	output, err = git("", "show", "tools/foo/v2.0.0:tools/foo/go.mod")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "module example.com/test/tools/foo/v2")
+	output, err = git("", "show", "tools/foo/v2.0.0:tools/foo/main.go")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "example.com/test/tools/foo/v2/bar")
+	output, err = git("", "status", "--porcelain")
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+
+	_, err = git("", "tag", "tools/foo/v3.1.0")
+	assert.NoError(t, err)
+	_, stderr, code := execMainCode(t, "--module", dir, "--minor")
+	assert.Equal(t, exitInvalidVersion, code, "the module path is checked without a major bump")
+	assert.Contains(t, stderr, "expected 'example.com/test/tools/foo/v3'")
+}
+
+func TestMainSuggest(t *testing.T) {
+	_ = prepareGit(t)
+
+	dir := prepareModule(t, ".")
+	_, err := git("", "tag", "v0.1.0")
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo.go"), []byte("package test\n\nconst Foo = 1\n"), 0o600))
+	_, err = git("", "add", "foo.go")
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "Add foo.go")
+	assert.NoError(t, err)
+
+	stdout, _ := execMain(t, "suggest")
+	assert.Contains(t, stdout, "Suggested bump: MINOR (v0.2.0)")
+	assert.Contains(t, stdout, "* package example.com/test: added")
+}
+
+func TestMainGoBackend(t *testing.T) {
+	prepareCommit := prepareGit(t)
+	_, err := git("", "tag", "v1.1.0")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, err = git("", "commit", "--allow-empty", "-m", "feat: test feature")
+	assert.NoError(t, err)
+
+	stdout, _ := execMain(t, "--backend", "go", "--find-tag")
+	assert.Equal(t, "v1.1.0", stdout)
+
+	stdout, _ = execMain(t, "--backend", "go", "--auto", "--dry-run")
+	assert.Contains(t, stdout, "Bump MINOR version: new feature in")
+	assert.Contains(t, stdout, "feat: test feature")
+	assert.Contains(t, stdout, "commit-#1")
+
+	stdout, _ = execMain(t, "--backend", "go", "--auto", "--auto-push")
+	assert.Contains(t, stdout, "The tag 'v1.2.0' has been pushed to the remote 'origin'")
+	assert.Contains(t, stdout, "Bump version v1.2.0")
+	output, err := git("", "cat-file", "-t", "v1.2.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "tag", output)
+	output, err = git("", "ls-remote", "--tags", "origin")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "v1.2.0")
+
+	_, _, code := execMainCode(t, "--backend", "test-backend")
+	assert.Equal(t, exitInvalidFlags, code)
+}
+
+func TestMainConfig(t *testing.T) {
+	prepareCommit := prepareGit(t)
+	remoteDir := t.TempDir()
+	cmd := exec.Command("git", "init", "--bare")
+	cmd.Dir = remoteDir
+	assert.NoError(t, cmd.Run())
+	_, err := git("", "remote", "add", "upstream", remoteDir)
+	assert.NoError(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(".bumptag.yaml", []byte("prefix: release-\nlevel: patch\n"), 0o600))
+	_, err = git("", "tag", "release-1.1.0")
+	assert.NoError(t, err)
+	prepareCommit()
+
+	stdout, _ := execMain(t, "--find-tag")
+	assert.Equal(t, "release-1.1.0", stdout)
+	stdout, _ = execMain(t, "--dry-run")
+	assert.Contains(t, stdout, "Bump version release-1.1.1")
+
+	_, err = git("", "config", "--local", "bumptag.level", "major")
+	assert.NoError(t, err)
+	stdout, _ = execMain(t, "--dry-run")
+	assert.Contains(t, stdout, "Bump version release-2.0.0")
+
+	t.Setenv("BUMPTAG_LEVEL", "minor")
+	stdout, _ = execMain(t, "--dry-run")
+	assert.Contains(t, stdout, "Bump version release-1.2.0")
+
+	stdout, _ = execMain(t, "--dry-run", "--patch")
+	assert.Contains(t, stdout, "Bump version release-1.1.1")
+	stdout, _ = execMain(t, "--find-tag", "--prefix", "v", "--strategy", "highest")
+	assert.Empty(t, stdout)
+
+	t.Setenv("BUMPTAG_REMOTE", "upstream")
+	t.Setenv("BUMPTAG_AUTO_PUSH", "true")
+	stdout, _ = execMain(t)
+	assert.Contains(t, stdout, "The tag 'release-1.2.0' has been pushed to the remote 'upstream'")
+	output, err := git("", "ls-remote", "--tags", "upstream")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "release-1.2.0")
+
+	t.Setenv("BUMPTAG_SIGN", "test-value")
+	_, _, code := execMainCode(t, "--dry-run")
+	assert.Equal(t, exitFailure, code)
+}
+
+func TestMainTemplate(t *testing.T) {
+	prepareCommit := prepareGit(t)
+	_, err := git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	prepareCommit()
+
+	assert.NoError(t, ioutil.WriteFile("tag.tmpl", []byte("Release {{.Version}} after {{.PreviousTag}}\n"+
+		"{{range .Commits}}- {{.Subject}} by {{.Author}}\n{{end}}"), 0o600))
+	assert.NoError(t, ioutil.WriteFile(".bumptag.yaml", []byte("template-file: tag.tmpl\n"), 0o600))
+	stdout, _ := execMain(t, "--dry-run")
+	assert.Contains(t, stdout, "Release 1.1.0 after v1.0.0\n- commit-#")
+	assert.Contains(t, stdout, " by Test Example\n")
+
+	assert.NoError(t, ioutil.WriteFile("other.tmpl", []byte("{{upper .TagName}}: {{.ChangeLog}}"), 0o600))
+	stdout, _ = execMain(t, "--dry-run", "--template-file", "other.tmpl")
+	assert.Contains(t, stdout, "V1.1.0: * ")
+
+	assert.NoError(t, ioutil.WriteFile("other.tmpl", []byte("{{.TagName"), 0o600))
+	_, _, code := execMainCode(t, "--dry-run", "--template-file", "other.tmpl")
+	assert.Equal(t, exitFailure, code)
+}
+
+func TestMainGroup(t *testing.T) {
+	prepareCommit := prepareGit(t)
+	_, err := git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, err = git("", "commit", "--allow-empty", "-m", "feat(cli): add --group")
+	assert.NoError(t, err)
+	_, err = git("", "commit", "--allow-empty", "-m", "fix: test")
+	assert.NoError(t, err)
+
+	stdout, _ := execMain(t, "--dry-run", "--group")
+	assert.Contains(t, stdout, "Features:\n* ")
+	assert.Contains(t, stdout, " **cli:** add --group\n\nBug Fixes:\n* ")
+	assert.Contains(t, stdout, "Other:\n* ")
+	assert.Contains(t, stdout, " commit-#")
+
+	assert.NoError(t, ioutil.WriteFile(".bumptag.yaml", []byte("group: true\nsection-bug-fixes: ''\n"), 0o600))
+	stdout, _ = execMain(t, "--dry-run")
+	assert.Contains(t, stdout, "Features:\n* ")
+	assert.NotContains(t, stdout, "Bug Fixes:")
+	assert.Contains(t, stdout, " fix: test")
+
+	stdout, _ = execMain(t, "--dry-run", "--group=false")
+	assert.NotContains(t, stdout, "Features:")
+}
+
+func TestMainChangelogFile(t *testing.T) {
+	prepareCommit := prepareGit(t)
+	_, err := git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	prepareCommit()
+
+	stdout, _ := execMain(t, "--dry-run", "--changelog-file", "CHANGELOG.md")
+	assert.Contains(t, stdout, "Bump version v1.1.0")
+	_, err = os.Stat("CHANGELOG.md")
+	assert.True(t, os.IsNotExist(err))
+
+	stdout, _ = execMain(t, "--changelog-file", "CHANGELOG.md")
+	assert.Contains(t, stdout, "tag v1.1.0")
+	data, err := ioutil.ReadFile("CHANGELOG.md")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "## [1.1.0] - ")
+	assert.Contains(t, string(data), "\n\n* ")
+	output, err := git("", "log", "-1", "--pretty=%s%d")
+	assert.NoError(t, err)
+	assert.Equal(t, "Update CHANGELOG.md for v1.1.0 (HEAD -> master, tag: v1.1.0)", output)
+
+	assert.NoError(t, os.Mkdir("docs", 0o700))
+	t.Chdir("docs")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join("..", ".bumptag.yaml"), []byte("changelog-file: CHANGELOG.md\n"), 0o600))
+	_, err = git("", "add", filepath.Join("..", ".bumptag.yaml"))
+	assert.NoError(t, err)
+	_, err = git("", "commit", "-m", "Add .bumptag.yaml")
+	assert.NoError(t, err)
+	execMain(t, "-s")
+	data, err = ioutil.ReadFile(filepath.Join("..", "CHANGELOG.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "## [1.2.0] - ")
+	assert.Contains(t, string(data), " Add .bumptag.yaml\n\n## [1.1.0] - ")
+}
+
+func TestMainChangelog(t *testing.T) {
+	prepareCommit := prepareGit(t)
+	_, err := git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	prepareCommit()
+	_, err = git("", "tag", "-a", "-m", "Bump version v1.1.0", "-m", "* abc1234 test-annotation", "v1.1.0")
+	assert.NoError(t, err)
+	prepareCommit()
+
+	stdout, _ := execMain(t, "changelog")
+	assert.Contains(t, stdout, "* ")
+	assert.NotContains(t, stdout, "Bump version")
+
+	stdout, _ = execMain(t, "changelog", "--all", "--dry-run")
+	assert.Contains(t, stdout, "# Changelog\n")
+	assert.Contains(t, stdout, "\n## [1.1.0] - ")
+	assert.Contains(t, stdout, "\n## [1.0.0] - ")
+	assert.NotContains(t, stdout, "test-annotation")
+	_, err = os.Stat("CHANGELOG.md")
+	assert.True(t, os.IsNotExist(err))
+
+	stdout, _ = execMain(t, "changelog", "--all", "--annotations")
+	assert.Equal(t, "The change log of 2 releases has been written to 'CHANGELOG.md'\n", stdout)
+	data, err := ioutil.ReadFile("CHANGELOG.md")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "\n\n* abc1234 test-annotation\n\n## [1.0.0] - ")
+	output, err := git("", "status", "--porcelain")
+	assert.NoError(t, err)
+	assert.Equal(t, "?? CHANGELOG.md", output)
+
+	stdout, _ = execMain(t, "changelog", "--all", "--changelog-file", "HISTORY.md", "-s")
+	assert.Empty(t, stdout)
+	_, err = os.Stat("HISTORY.md")
+	assert.NoError(t, err)
+
+	_, _, code := execMainCode(t, "changelog", "--all-modules")
+	assert.Equal(t, exitInvalidFlags, code)
+}
+
+func TestMainOutputJSON(t *testing.T) {
+	prepareCommit := prepareGit(t)
+	_, err := git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	prepareCommit()
+
+	var tag map[string]interface{}
+	stdout, _ := execMain(t, "--find-tag", "--output", "json")
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &tag))
+	assert.Equal(t, "v1.0.0", tag["tag"])
+	assert.Equal(t, float64(1), tag["major"])
+
+	var release map[string]interface{}
+	stdout, _ = execMain(t, "--dry-run", "--output", "json")
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &release))
+	assert.Equal(t, "v1.0.0", release["previous_tag"])
+	assert.Equal(t, "v1.1.0", release["tag"])
+	assert.Equal(t, "minor", release["level"])
+	assert.Equal(t, true, release["dry_run"])
+	assert.Len(t, release["commits"], 1)
+
+	stdout, _ = execMain(t, "--output", "json", "-a", "-p")
+	release = nil
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &release))
+	assert.Equal(t, "v1.0.1", release["tag"])
+	assert.Equal(t, "origin", release["remote"])
+	assert.Equal(t, false, release["signed"])
+	assert.Equal(t, false, release["dry_run"])
+	assert.Contains(t, release["annotation"], "Bump version v1.0.1\n\n* ")
+
+	_ = prepareModule(t, "tools/foo")
+	var releases []map[string]interface{}
+	stdout, _ = execMain(t, "--all-modules", "--dry-run", "--output", "json")
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &releases))
+	assert.Len(t, releases, 1)
+	assert.Equal(t, "tools/foo/v0.1.0", releases[0]["tag"])
+	var tags []map[string]interface{}
+	stdout, _ = execMain(t, "--all-modules", "--find-tag", "--output", "json")
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &tags))
+	assert.Len(t, tags, 1)
+	assert.Equal(t, "tools/foo", tags[0]["module"])
+
+	_, _, code := execMainCode(t, "--output", "yaml")
+	assert.Equal(t, exitInvalidFlags, code)
+}
+
+func TestMainCI(t *testing.T) {
+	prepareCommit := prepareGit(t)
+	_, err := git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	prepareCommit()
+
+	mockCI(t, "true", "")
+	output := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_OUTPUT", output)
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	stdout, _ := execMain(t, "--find-tag", "--ci")
+	assert.Equal(t, "v1.0.0", stdout)
+	data, err := ioutil.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "tag=v1.0.0\nprevious_tag=\nversion=1.0.0\nchangelog=\n", string(data))
+
+	assert.NoError(t, os.Remove(output))
+	stdout, _ = execMain(t, "--ci", "-s")
+	assert.Empty(t, stdout)
+	data, err = ioutil.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "tag=v1.1.0\nprevious_tag=v1.0.0\nversion=1.1.0\nchangelog=* ")
+
+	mockCI(t, "", "true")
+	stdout, _ = execMain(t, "--ci", "--dry-run")
+	assert.Contains(t, stdout, "Bump version v1.2.0")
+	data, err = ioutil.ReadFile(gitlabDotenvFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "BUMPTAG_TAG=v1.2.0\nBUMPTAG_PREVIOUS_TAG=v1.1.0\nBUMPTAG_VERSION=1.2.0\n", string(data))
+
+	mockCI(t, "", "")
+	_, _, code := execMainCode(t, "--ci", "--dry-run")
+	assert.Equal(t, exitFailure, code)
+	_, stderr, code := execMainCode(t, "--ci", "-s")
+	assert.Equal(t, exitFailure, code)
+	assert.Equal(t, "Error: the CI environment is not detected, neither GITHUB_ACTIONS nor GITLAB_CI is set\n", stderr)
+	mockCI(t, "true", "")
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(output, "missing"))
+	_, _, code = execMainCode(t, "--ci", "-s")
+	assert.Equal(t, exitFailure, code)
+	tags, err := git("", "tag", "--list", "v1.2.0")
+	assert.NoError(t, err)
+	assert.Empty(t, tags, "the tag is not created if the CI outputs cannot be written")
+	_, _, code = execMainCode(t, "--ci", "--all-modules")
+	assert.Equal(t, exitInvalidFlags, code)
+}
+
+func TestMainRelease(t *testing.T) {
+	_ = prepareGit(t)
+	var body map[string]interface{}
+	var asset string
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("POST /repos/owner/repo/releases", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"html_url": "https://github.com/owner/repo/releases/tag/v0.1.0",
+			"upload_url": "`+server.URL+`/uploads{?name,label}"}`)
+	})
+	mux.HandleFunc("POST /uploads", func(w http.ResponseWriter, r *http.Request) {
+		asset = r.URL.Query().Get("name")
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	t.Setenv("BUMPTAG_RELEASE_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("BUMPTAG_RELEASE_PROJECT", "owner/repo")
+	_, stderr, code := execMainCode(t, "--auto-push", "--release", "github", "--release-url", server.URL)
+	assert.Equal(t, exitFailure, code)
+	assert.Equal(t, "Error: the API token is not set, set BUMPTAG_RELEASE_TOKEN or GITHUB_TOKEN\n", stderr)
+	output, err := git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Empty(t, output, "the tag is not created without the token")
+
+	t.Setenv("BUMPTAG_RELEASE_TOKEN", "test-token")
+	assets := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(assets, "app.tar.gz"), []byte("test"), 0o600))
+
+	_, _, code = execMainCode(t, "--release", "github", "--release-url", server.URL)
+	assert.Equal(t, exitInvalidFlags, code)
+	_, _, code = execMainCode(t, "--auto-push", "--release", "github", "--release-url", server.URL,
+		"--release-asset", filepath.Join(assets, "*.zip"))
+	assert.Equal(t, exitFailure, code)
+	output, err = git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+
+	stdout, _ := execMain(t, "--auto-push", "--release", "github", "--release-url", server.URL,
+		"--release-asset", filepath.Join(assets, "*.tar.gz"), "--output", "json")
+	var o releaseOutput
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &o))
+	assert.Equal(t, "https://github.com/owner/repo/releases/tag/v0.1.0", o.ReleaseURL)
+	assert.Equal(t, "v0.1.0", body["tag_name"])
+	assert.Equal(t, false, body["prerelease"])
+	assert.Equal(t, "app.tar.gz", asset)
+}
+
+func TestMainChecks(t *testing.T) {
+	prepareCommit := prepareGit(t)
+	_, err := git("", "tag", "v1.0.0")
+	assert.NoError(t, err)
+	prepareCommit()
+
+	assert.NoError(t, ioutil.WriteFile("README.md", []byte("test"), 0o600))
+	_, err = git("", "add", "README.md")
+	assert.NoError(t, err)
+	_, stderr, code := execMainCode(t)
+	assert.Equal(t, exitDirtyTree, code)
+	assert.Equal(t, "Error: the clean check failed: the working tree has uncommitted changes: README.md\n", stderr)
+	_, err = git("", "commit", "-m", "Add README.md")
+	assert.NoError(t, err)
+
+	t.Setenv("BUMPTAG_BRANCHES", "main,release/*")
+	_, stderr, code = execMainCode(t)
+	assert.Equal(t, exitCheckFailed, code)
+	assert.Equal(t, "Error: the branch check failed: the branch 'master' is not a release branch: main, release/*\n", stderr)
+
+	_, _, code = execMainCode(t, "--skip-check", "branch", "v0.9.0")
+	assert.Equal(t, exitInvalidVersion, code)
+
+	_, err = git("", "push", "origin", "master")
+	assert.NoError(t, err)
+	_, err = git("", "reset", "--hard", "HEAD~1")
+	assert.NoError(t, err)
+	_, stderr, code = execMainCode(t, "--skip-check", "branch")
+	assert.Equal(t, exitCheckFailed, code)
+	assert.Contains(t, stderr, "the branch is behind its upstream by 1 commits")
+
+	_, _ = execMain(t, "--skip-check", "branch", "--skip-check", "behind")
+	output, err := git("", "tag", "--list")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0\nv1.1.0", output)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// gitlabDotenvFile is the dotenv report written in GitLab CI, it should be declared in the job:
+//
+//	artifacts:
+//	  reports:
+//	    dotenv: bumptag.env
+const gitlabDotenvFile = "bumptag.env"
+
+// ciOutputs are the values passed to the next steps of the CI pipeline.
+type ciOutputs struct {
+	Tag         string
+	PreviousTag string
+	Version     string
+	ChangeLog   string
+}
+
+var (
+	errNoCI           = errors.New("the CI environment is not detected, neither GITHUB_ACTIONS nor GITLAB_CI is set")
+	errNoGitHubOutput = errors.New("the GITHUB_OUTPUT environment variable is not set")
+)
+
+// checkCI checks the CI environment is detected and the step outputs of GitHub Actions can be written,
+// so the tag is not created if its outputs cannot be passed to the next steps.
+func checkCI() error {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		filename := os.Getenv("GITHUB_OUTPUT")
+		if len(filename) == 0 {
+			return errNoGitHubOutput
+		}
+		return appendFile(filename, "")
+	case os.Getenv("GITLAB_CI") == "true":
+		return nil
+	}
+	return errNoCI
+}
+
+// writeCIOutputs detects the CI environment and writes the outputs:
+// the step outputs and the step summary in GitHub Actions or the dotenv report in GitLab CI.
+func writeCIOutputs(o *ciOutputs) error {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return writeGitHubOutputs(o)
+	case os.Getenv("GITLAB_CI") == "true":
+		return writeGitLabDotenv(o)
+	}
+	return errNoCI
+}
+
+func appendFile(filename, content string) error {
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// githubOutput formats the output in the GITHUB_OUTPUT file, the multiline values use a random delimiter.
+func githubOutput(name, value string) (string, error) {
+	if !strings.Contains(value, "\n") {
+		return name + "=" + value + "\n", nil
+	}
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	delimiter := "bumptag_" + hex.EncodeToString(data)
+	return fmt.Sprintf("%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter), nil
+}
+
+func writeGitHubOutputs(o *ciOutputs) error {
+	filename := os.Getenv("GITHUB_OUTPUT")
+	if len(filename) == 0 {
+		return errNoGitHubOutput
+	}
+	var content strings.Builder
+	for _, output := range [][2]string{
+		{"tag", o.Tag},
+		{"previous_tag", o.PreviousTag},
+		{"version", o.Version},
+		{"changelog", o.ChangeLog},
+	} {
+		line, err := githubOutput(output[0], output[1])
+		if err != nil {
+			return err
+		}
+		content.WriteString(line)
+	}
+	if err := appendFile(filename, content.String()); err != nil {
+		return err
+	}
+
+	summary := os.Getenv("GITHUB_STEP_SUMMARY")
+	if len(summary) == 0 {
+		return nil
+	}
+	return appendFile(summary, githubSummary(o))
+}
+
+// githubSummary returns the markdown of the step summary.
+func githubSummary(o *ciOutputs) string {
+	lines := []string{"### " + o.Tag, ""}
+	if len(o.PreviousTag) > 0 {
+		lines = append(lines, "Previous tag: "+o.PreviousTag, "")
+	}
+	if len(o.ChangeLog) > 0 {
+		lines = append(lines, o.ChangeLog, "")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// writeGitLabDotenv writes the dotenv report, the change log is skipped, because GitLab does not support
+// the multiline values.
+func writeGitLabDotenv(o *ciOutputs) error {
+	content := strings.Join([]string{
+		"BUMPTAG_TAG=" + o.Tag,
+		"BUMPTAG_PREVIOUS_TAG=" + o.PreviousTag,
+		"BUMPTAG_VERSION=" + o.Version,
+	}, "\n") + "\n"
+	return os.WriteFile(gitlabDotenvFile, []byte(content), 0o600)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockCI(t *testing.T, github, gitlab string) {
+	for name, value := range map[string]string{"GITHUB_ACTIONS": github, "GITLAB_CI": gitlab} {
+		t.Setenv(name, value)
+		if len(value) == 0 {
+			assert.NoError(t, os.Unsetenv(name))
+		}
+	}
+}
+
+func TestGithubOutput(t *testing.T) {
+	line, err := githubOutput("tag", "v1.2.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "tag=v1.2.0\n", line)
+
+	line, err = githubOutput("changelog", "* abc1234 test\n* def5678 test")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^changelog<<bumptag_[0-9a-f]{32}\n\* abc1234 test\n\* def5678 test\nbumptag_[0-9a-f]{32}\n$`, line)
+	parts := regexp.MustCompile(`bumptag_[0-9a-f]{32}`).FindAllString(line, -1)
+	assert.Len(t, parts, 2)
+	assert.Equal(t, parts[0], parts[1])
+}
+
+func TestCheckCI(t *testing.T) {
+	mockCI(t, "", "")
+	assert.EqualError(t, checkCI(), "the CI environment is not detected, neither GITHUB_ACTIONS nor GITLAB_CI is set")
+
+	mockCI(t, "true", "")
+	t.Setenv("GITHUB_OUTPUT", "")
+	assert.EqualError(t, checkCI(), "the GITHUB_OUTPUT environment variable is not set")
+
+	dir := t.TempDir()
+	t.Setenv("GITHUB_OUTPUT", filepath.Join(dir, "missing", "output"))
+	assert.Error(t, checkCI())
+
+	output := filepath.Join(dir, "output")
+	t.Setenv("GITHUB_OUTPUT", output)
+	assert.NoError(t, checkCI())
+	data, err := ioutil.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+
+	mockCI(t, "", "true")
+	assert.NoError(t, checkCI())
+}
+
+func TestWriteCIOutputs(t *testing.T) {
+	t.Chdir(t.TempDir())
+	o := &ciOutputs{Tag: "v1.2.0", PreviousTag: "v1.1.0", Version: "1.2.0", ChangeLog: "* abc1234 test\n* def5678 test"}
+
+	mockCI(t, "", "")
+	assert.EqualError(t, writeCIOutputs(o), "the CI environment is not detected, neither GITHUB_ACTIONS nor GITLAB_CI is set")
+
+	mockCI(t, "true", "")
+	t.Setenv("GITHUB_OUTPUT", "")
+	assert.EqualError(t, writeCIOutputs(o), "the GITHUB_OUTPUT environment variable is not set")
+
+	output := filepath.Join(t.TempDir(), "output")
+	assert.NoError(t, ioutil.WriteFile(output, []byte("other=test\n"), 0o600))
+	t.Setenv("GITHUB_OUTPUT", output)
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	assert.NoError(t, writeCIOutputs(o))
+	data, err := ioutil.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^other=test\ntag=v1.2.0\nprevious_tag=v1.1.0\nversion=1.2.0\nchangelog<<bumptag_\w+\n\* abc1234 test\n`, string(data))
+
+	summary := filepath.Join(t.TempDir(), "summary")
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+	assert.NoError(t, writeCIOutputs(o))
+	data, err = ioutil.ReadFile(summary)
+	assert.NoError(t, err)
+	assert.Equal(t, "### v1.2.0\n\nPrevious tag: v1.1.0\n\n* abc1234 test\n* def5678 test\n\n", string(data))
+
+	mockCI(t, "", "true")
+	assert.NoError(t, writeCIOutputs(o))
+	data, err = ioutil.ReadFile(gitlabDotenvFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "BUMPTAG_TAG=v1.2.0\nBUMPTAG_PREVIOUS_TAG=v1.1.0\nBUMPTAG_VERSION=1.2.0\n", string(data))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/sv-tools/bumptag/bumptag"
+)
+
+// The exit codes of the bumptag tool, exitInvalidFlags is used by the flag package too.
+const (
+	exitOK             = 0
+	exitFailure        = 1
+	exitInvalidFlags   = 2
+	exitDirtyTree      = 3
+	exitTagExists      = 4
+	exitInvalidVersion = 5
+	exitNoRemote       = 6
+	exitGitFailed      = 7
+	exitCheckFailed    = 8
+	exitInterrupted    = 42
+)
+
+// flagError is the misuse of the flags not detected by the flag package, e.g. an unknown value or the conflicting flags.
+type flagError string
+
+func (e flagError) Error() string {
+	return string(e)
+}
+
+// exitCode returns the exit code of the error, the specific errors win over the failed git commands
+// and the failed git commands win over the failed checks.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, new(flagError)):
+		return exitInvalidFlags
+	case errors.Is(err, bumptag.ErrDirtyTree):
+		return exitDirtyTree
+	case errors.Is(err, bumptag.ErrTagExists):
+		return exitTagExists
+	case errors.Is(err, bumptag.ErrInvalidVersion):
+		return exitInvalidVersion
+	case errors.Is(err, bumptag.ErrNoRemote):
+		return exitNoRemote
+	case errors.Is(err, bumptag.ErrGitFailed):
+		return exitGitFailed
+	case errors.Is(err, bumptag.ErrCheckFailed):
+		return exitCheckFailed
+	}
+	return exitFailure
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sv-tools/bumptag/bumptag"
+)
+
+func TestExitCode(t *testing.T) {
+	gitErr := &bumptag.GitError{Args: []string{"git", "push"}, Err: errors.New("exit status 128")}
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{nil, exitOK},
+		{errors.New("test-error"), exitFailure},
+		{flagError("test-error"), exitInvalidFlags},
+		{fmt.Errorf("%w: CHANGELOG.md", bumptag.ErrDirtyTree), exitDirtyTree},
+		{fmt.Errorf("%w: 'v1.0.0'", bumptag.ErrTagExists), exitTagExists},
+		{fmt.Errorf("module 'tools/foo': %w", bumptag.ErrInvalidVersion), exitInvalidVersion},
+		{bumptag.ErrNoRemote, exitNoRemote},
+		{fmt.Errorf("the tag is not pushed: %w", gitErr), exitGitFailed},
+		{fmt.Errorf("%w: %w", bumptag.ErrTagExists, gitErr), exitTagExists},
+		{&bumptag.CheckError{Check: bumptag.CheckBranch, Err: errors.New("test-error")}, exitCheckFailed},
+		{&bumptag.CheckError{Check: bumptag.CheckBehind, Err: gitErr}, exitGitFailed},
+		{&bumptag.CheckError{Check: bumptag.CheckClean, Err: bumptag.ErrDirtyTree}, exitDirtyTree},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.code, exitCode(c.err), c.err)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sv-tools/bumptag/bumptag"
+)
+
+// The output formats.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+func validateOutput(format string) error {
+	switch format {
+	case outputText, outputJSON:
+		return nil
+	}
+	return fmt.Errorf("unknown output format '%s'", format)
+}
+
+type versionOutput struct {
+	Version    string `json:"version"`
+	Major      int64  `json:"major"`
+	Minor      int64  `json:"minor"`
+	Patch      int64  `json:"patch"`
+	PreRelease string `json:"pre_release"`
+	Metadata   string `json:"metadata"`
+}
+
+func newVersionOutput(v *bumptag.Version) versionOutput {
+	return versionOutput{
+		Version:    v.String(),
+		Major:      v.Major,
+		Minor:      v.Minor,
+		Patch:      v.Patch,
+		PreRelease: string(v.PreRelease),
+		Metadata:   v.Metadata,
+	}
+}
+
+// tagOutput is the JSON output of --find-tag.
+type tagOutput struct {
+	Module string `json:"module"`
+	Tag    string `json:"tag"`
+	versionOutput
+}
+
+func newTagOutput(m *bumptag.Module, v *bumptag.Version, tagName string) *tagOutput {
+	return &tagOutput{Module: m.String(), Tag: tagName, versionOutput: newVersionOutput(v)}
+}
+
+// checkOutput is the JSON output of --check.
+type checkOutput struct {
+	Module string `json:"module"`
+	Tag    string `json:"tag"`
+	// Outdated are the files not matching the tag.
+	Outdated []string `json:"outdated"`
+}
+
+type commitOutput struct {
+	Hash        string    `json:"hash"`
+	Subject     string    `json:"subject"`
+	Body        string    `json:"body"`
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"author_email"`
+	Date        time.Time `json:"date"`
+}
+
+// releaseOutput is the JSON output of a new tag, the remote is empty if the tag is not pushed
+// and the release URL is empty if the release is not created on the forge.
+type releaseOutput struct {
+	Module          string `json:"module"`
+	PreviousTag     string `json:"previous_tag"`
+	PreviousVersion string `json:"previous_version"`
+	Tag             string `json:"tag"`
+	versionOutput
+	Level      string         `json:"level"`
+	Reason     string         `json:"reason"`
+	Commits    []commitOutput `json:"commits"`
+	Signed     bool           `json:"signed"`
+	Remote     string         `json:"remote"`
+	ReleaseURL string         `json:"release_url"`
+	DryRun     bool           `json:"dry_run"`
+	Annotation string         `json:"annotation"`
+}
+
+func newReleaseOutput(r *bumptag.Release, signed bool, remote string, dryRun bool) *releaseOutput {
+	commits := make([]commitOutput, 0, len(r.Commits))
+	for _, c := range r.Commits {
+		commits = append(commits, commitOutput{
+			Hash:        c.Hash,
+			Subject:     c.Subject,
+			Body:        c.Body,
+			Author:      c.Author,
+			AuthorEmail: c.AuthorEmail,
+			Date:        c.Date,
+		})
+	}
+	return &releaseOutput{
+		Module:          r.Module.String(),
+		PreviousTag:     r.PreviousTag,
+		PreviousVersion: r.PreviousVersion.String(),
+		Tag:             r.TagName,
+		versionOutput:   newVersionOutput(r.Version),
+		Level:           r.Level.String(),
+		Reason:          r.Reason,
+		Commits:         commits,
+		Signed:          signed,
+		Remote:          remote,
+		DryRun:          dryRun,
+		Annotation:      r.Annotation,
+	}
+}
+
+// printJSON prints the value as an indented JSON document.
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sv-tools/bumptag/bumptag"
+)
+
+func TestValidateOutput(t *testing.T) {
+	assert.NoError(t, validateOutput(outputText))
+	assert.NoError(t, validateOutput(outputJSON))
+	assert.EqualError(t, validateOutput("yaml"), "unknown output format 'yaml'")
+}
+
+func TestNewReleaseOutput(t *testing.T) {
+	v, err := bumptag.NewVersion("2.0.0-rc.1+build.5")
+	assert.NoError(t, err)
+	previous, err := bumptag.NewVersion("1.2.0")
+	assert.NoError(t, err)
+	r := &bumptag.Release{
+		Module:          bumptag.RootModule(),
+		PreviousTag:     "v1.2.0",
+		PreviousVersion: *previous,
+		Version:         v,
+		TagName:         "v2.0.0-rc.1+build.5",
+		Level:           bumptag.BumpMajor,
+		Reason:          "breaking change",
+		Commits: []*bumptag.Commit{{
+			Hash:        "abc1234",
+			Author:      "John Doe",
+			AuthorEmail: "john@example.com",
+			Date:        time.Date(2020, 5, 17, 10, 0, 0, 0, time.UTC),
+			Subject:     "feat!: test",
+		}},
+		Annotation: "test-annotation",
+	}
+	data, err := json.Marshal(newReleaseOutput(r, true, "origin", false))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"module": ".",
+		"previous_tag": "v1.2.0",
+		"previous_version": "1.2.0",
+		"tag": "v2.0.0-rc.1+build.5",
+		"version": "2.0.0-rc.1+build.5",
+		"major": 2,
+		"minor": 0,
+		"patch": 0,
+		"pre_release": "rc.1",
+		"metadata": "build.5",
+		"level": "major",
+		"reason": "breaking change",
+		"commits": [{
+			"hash": "abc1234",
+			"subject": "feat!: test",
+			"body": "",
+			"author": "John Doe",
+			"author_email": "john@example.com",
+			"date": "2020-05-17T10:00:00Z"
+		}],
+		"signed": true,
+		"remote": "origin",
+		"release_url": "",
+		"dry_run": false,
+		"annotation": "test-annotation"
+	}`, string(data))
+
+	data, err = json.Marshal(newTagOutput(bumptag.ModuleAt("tools/foo"), previous, "tools/foo/v1.2.0"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"module": "tools/foo",
+		"tag": "tools/foo/v1.2.0",
+		"version": "1.2.0",
+		"major": 1,
+		"minor": 2,
+		"patch": 0,
+		"pre_release": "",
+		"metadata": ""
+	}`, string(data))
+}
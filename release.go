@@ -0,0 +1,318 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sv-tools/bumptag/bumptag"
+)
+
+func editAnnotation(editor string, r *bumptag.Release) (err error) {
+	r.Annotation, err = edit(editor, r.Annotation)
+	return err
+}
+
+func dryRun(r *bumptag.Release) string {
+	var output []string
+	if len(r.Reason) > 0 {
+		output = append(output, fmt.Sprintf("Bump %s version: %s", strings.ToUpper(r.Level.String()), r.Reason), "")
+	}
+	return strings.Join(append(output, r.Annotation), "\n")
+}
+
+// findTag shows the latest tag of the module.
+func findTag(args *bumptagArgs, repo *bumptag.Repo, m *bumptag.Module) error {
+	v, currentTagName, err := repo.FindTag(m, *args.strategy)
+	if err != nil {
+		return err
+	}
+	if *args.ci {
+		if err := writeCIOutputs(&ciOutputs{Tag: currentTagName, Version: v.String()}); err != nil {
+			return err
+		}
+	}
+	if *args.output == outputJSON {
+		return printJSON(newTagOutput(m, v, currentTagName))
+	}
+	fmt.Print(currentTagName)
+	return nil
+}
+
+// checkFiles checks that the version files and the files of the replacements match the latest tag of the module.
+func checkFiles(args *bumptagArgs, repo *bumptag.Repo, m *bumptag.Module) error {
+	t := args.newTagger(repo)
+	if len(t.BumpFiles) == 0 && len(t.Replacements) == 0 {
+		return flagError("--check requires --bump-files or the 'replace' setting")
+	}
+	v, tagName, err := repo.FindTag(m, *args.strategy)
+	if err != nil {
+		return err
+	}
+	if len(tagName) == 0 {
+		return errors.New("no tag to check the files against")
+	}
+	outdated, err := t.OutdatedFiles(&bumptag.Release{Module: m, Version: v, TagName: tagName})
+	if err != nil {
+		return err
+	}
+	if *args.output == outputJSON {
+		o := &checkOutput{Module: m.String(), Tag: tagName, Outdated: outdated}
+		if o.Outdated == nil {
+			o.Outdated = []string{}
+		}
+		if err := printJSON(o); err != nil {
+			return err
+		}
+	}
+	if len(outdated) > 0 {
+		return fmt.Errorf("%w: the files do not match the tag '%s': %s",
+			bumptag.ErrCheckFailed, tagName, strings.Join(outdated, ", "))
+	}
+	if !*args.silent && *args.output != outputJSON {
+		fmt.Printf("The files match the tag '%s'\n", tagName)
+	}
+	return nil
+}
+
+// release creates the next tag of the module or shows it with --dry-run.
+func release(args *bumptagArgs, repo *bumptag.Repo, m *bumptag.Module) error {
+	r, err := args.newBumper(repo, changeLogInput()).Prepare(m)
+	if err != nil {
+		return err
+	}
+	if *args.edit {
+		if err := editAnnotation(args.editor, r); err != nil {
+			return err
+		}
+	}
+
+	t := args.newTagger(repo)
+	p, err := args.newPublisher(repo)
+	if err != nil {
+		return err
+	}
+	var remote, releaseURL string
+	if *args.dryRun {
+		err = t.CheckModulePath(r)
+	} else {
+		remote, releaseURL, err = publishRelease(args, t, p, r)
+	}
+	if err != nil {
+		return err
+	}
+	if *args.ci {
+		o := &ciOutputs{Tag: r.TagName, PreviousTag: r.PreviousTag, Version: r.Version.String(), ChangeLog: r.ChangeLog}
+		if err := writeCIOutputs(o); err != nil {
+			return err
+		}
+	}
+	switch {
+	case *args.output == outputJSON:
+		o := newReleaseOutput(r, t.Sign, remote, *args.dryRun)
+		o.ReleaseURL = releaseURL
+		return printJSON(o)
+	case *args.dryRun:
+		fmt.Println(dryRun(r))
+	}
+	return nil
+}
+
+// rollBack undoes the changes of the tagger and reports them to stderr.
+func rollBack(t *bumptag.Tagger) {
+	undone, err := t.Rollback()
+	for _, description := range undone {
+		fmt.Fprintf(os.Stderr, "Rolled back %s\n", description)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+	}
+}
+
+// tagAndPush creates the tag and pushes it with --auto-push, the changes are rolled back if either fails
+// or on Ctrl-C, so the next run computes the same version. It returns the remote, empty if the tag is not pushed.
+func tagAndPush(args *bumptagArgs, t *bumptag.Tagger, r *bumptag.Release) (remote string, err error) {
+	onInterrupt(func() { rollBack(t) })
+	defer onInterrupt(nil)
+	defer func() {
+		if err != nil {
+			rollBack(t)
+		}
+		t.Forget()
+	}()
+
+	if err := t.Tag(r); err != nil {
+		return "", err
+	}
+	if *args.autoPush {
+		return t.Push(r)
+	}
+	return "", nil
+}
+
+// publishRelease creates and pushes the tag and creates the release on the forge if the publisher is not nil.
+// It returns the remote and the URL of the release, they are empty if the tag is not pushed or released.
+func publishRelease(
+	args *bumptagArgs, t *bumptag.Tagger, p *bumptag.Publisher, r *bumptag.Release,
+) (remote, releaseURL string, err error) {
+	if remote, err = tagAndPush(args, t, r); err != nil {
+		return "", "", err
+	}
+
+	quiet := *args.silent || *args.output == outputJSON
+	if *args.autoPush && !quiet {
+		fmt.Printf(
+			"The tag '%s' has been pushed to the remote '%s'",
+			r.TagName,
+			remote,
+		)
+	}
+	if !quiet {
+		output, err := t.Repo.Backend.ShowTag(r.TagName)
+		if err != nil {
+			return "", "", err
+		}
+		fmt.Println(output)
+	}
+	if p != nil {
+		if releaseURL, err = p.Publish(r, remote); err != nil {
+			return "", "", fmt.Errorf("the tag '%s' has been pushed, but the release is not created: %w", r.TagName, err)
+		}
+		if !quiet {
+			fmt.Printf("The release has been created: %s\n", releaseURL)
+		}
+	}
+	return remote, releaseURL, nil
+}
+
+// printReport prints the text report or the value as JSON.
+func printReport(args *bumptagArgs, report []string, v interface{}) error {
+	if *args.output == outputJSON {
+		return printJSON(v)
+	}
+	fmt.Println(strings.Join(report, "\n"))
+	return nil
+}
+
+// findAllTags shows the latest tags of all modules.
+func findAllTags(args *bumptagArgs, repo *bumptag.Repo, modules []*bumptag.Module) error {
+	var report []string
+	tags := make([]*tagOutput, 0, len(modules))
+	for _, m := range modules {
+		v, currentTagName, err := repo.FindTag(m, *args.strategy)
+		if err != nil {
+			return err
+		}
+		report = append(report, fmt.Sprintf("%s: %s", m, currentTagName))
+		tags = append(tags, newTagOutput(m, v, currentTagName))
+	}
+	return printReport(args, report, tags)
+}
+
+// changelog shows the change log of the next release of the module
+// or regenerates the change log file from all releases with --all.
+func changelog(args *bumptagArgs, repo *bumptag.Repo, m *bumptag.Module) error {
+	b := args.newBumper(repo, nil)
+	if !*args.all {
+		r, err := b.Prepare(m)
+		if err != nil {
+			return err
+		}
+		fmt.Println(r.ChangeLog)
+		return nil
+	}
+
+	releases, err := b.History(m, *args.annotations)
+	if err != nil {
+		return err
+	}
+	if *args.dryRun {
+		fmt.Print(bumptag.FormatChangelog(releases))
+		return nil
+	}
+	filename := *args.changelogFile
+	if len(filename) == 0 {
+		filename = bumptag.DefaultChangelogFile
+	}
+	rel, err := repo.WriteChangelogFile(filename, releases)
+	if err != nil {
+		return err
+	}
+	if !*args.silent {
+		fmt.Printf("The change log of %d releases has been written to '%s'\n", len(releases), rel)
+	}
+	return nil
+}
+
+// releaseAllModules creates the tags for every Go module of the repository changed since its latest tag.
+func releaseAllModules(args *bumptagArgs, repo *bumptag.Repo) error {
+	modules, err := repo.Modules()
+	if err != nil {
+		return err
+	}
+
+	if *args.findTag {
+		return findAllTags(args, repo, modules)
+	}
+
+	bumper := args.newBumper(repo, nil)
+	var releases []*bumptag.Release
+	var report []string
+	for _, m := range modules {
+		r, err := bumper.Prepare(m)
+		if err != nil {
+			return fmt.Errorf("module '%s': %w", m, err)
+		}
+		if len(r.Commits) == 0 {
+			report = append(report, fmt.Sprintf("Module '%s': no changes since '%s'", m, r.PreviousTag))
+			continue
+		}
+		if *args.edit {
+			if err := editAnnotation(args.editor, r); err != nil {
+				return err
+			}
+		}
+		releases = append(releases, r)
+		report = append(report, fmt.Sprintf("Module '%s': %s -> %s\n\n%s\n", m, r.PreviousTag, r.TagName, dryRun(r)))
+	}
+
+	tagger := args.newTagger(repo)
+	publisher, err := args.newPublisher(repo)
+	if err != nil {
+		return err
+	}
+	if *args.dryRun {
+		outputs, err := dryRunOutputs(tagger, releases)
+		if err != nil {
+			return err
+		}
+		return printReport(args, report, outputs)
+	}
+	outputs := make([]*releaseOutput, 0, len(releases))
+	for _, r := range releases {
+		remote, releaseURL, err := publishRelease(args, tagger, publisher, r)
+		if err != nil {
+			return fmt.Errorf("module '%s': %w", r.Module, err)
+		}
+		o := newReleaseOutput(r, tagger.Sign, remote, false)
+		o.ReleaseURL = releaseURL
+		outputs = append(outputs, o)
+	}
+	if *args.output == outputJSON {
+		return printJSON(outputs)
+	}
+	return nil
+}
+
+// dryRunOutputs checks the module paths of the releases like Tag does and returns the outputs of the dry run.
+func dryRunOutputs(t *bumptag.Tagger, releases []*bumptag.Release) ([]*releaseOutput, error) {
+	outputs := make([]*releaseOutput, 0, len(releases))
+	for _, r := range releases {
+		if err := t.CheckModulePath(r); err != nil {
+			return nil, fmt.Errorf("module '%s': %w", r.Module, err)
+		}
+		outputs = append(outputs, newReleaseOutput(r, t.Sign, "", true))
+	}
+	return outputs, nil
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sv-tools/bumptag/bumptag"
+)
+
+func TestDryRun(t *testing.T) {
+	r := &bumptag.Release{Annotation: "test-annotation"}
+	assert.Equal(t, "test-annotation", dryRun(r))
+
+	r.Level = bumptag.BumpMajor
+	r.Reason = "test-reason"
+	assert.Equal(t, "Bump MAJOR version: test-reason\n\ntest-annotation", dryRun(r))
+}